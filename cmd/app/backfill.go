@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go-clean-template/config"
+	"go-clean-template/internal/provider"
+	"go-clean-template/pkg/logger"
+	"go-clean-template/pkg/monitoring"
+	"os/signal"
+	"syscall"
+
+	"github.com/google/uuid"
+)
+
+// runBackfill persists a range of days synchronously:
+//
+//	app backfill -from 2024-01-01 -to 2024-01-31
+//	app backfill -resume <run id>
+//
+// Interrupted runs keep their progress and continue from the first unfinished day on resume.
+func runBackfill(cfg *config.Config, mon monitoring.Monitoring, lg logger.Logger, args []string) error {
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	from := fs.String("from", "", "first day to persist, YYYY-MM-DD")
+	to := fs.String("to", "", "last day to persist, YYYY-MM-DD")
+	resume := fs.String("resume", "", "id of a stopped backfill run to resume")
+	err := fs.Parse(args)
+	if err != nil {
+		return fmt.Errorf("fs.Parse: %w", err)
+	}
+
+	prov, err := provider.New(cfg, mon, lg)
+	if err != nil {
+		return fmt.Errorf("provider.New: %w", err)
+	}
+	defer prov.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	backfill := prov.GetBackfillService()
+
+	var id uuid.UUID
+	if *resume != "" {
+		id, err = uuid.Parse(*resume)
+		if err != nil {
+			return fmt.Errorf("uuid.Parse: %w", err)
+		}
+	} else {
+		run, err := backfill.Create(ctx, *from, *to)
+		if err != nil {
+			return fmt.Errorf("backfill.Create: %w", err)
+		}
+		id = run.ID
+	}
+
+	run, err := backfill.Run(ctx, id)
+	if err != nil {
+		return fmt.Errorf("backfill.Run: %w", err)
+	}
+
+	lg.Info(fmt.Sprintf("backfill run %s %s: %d/%d days done, %d failed",
+		run.ID, run.Status, run.Done, run.Total, run.Failed))
+	return nil
+}
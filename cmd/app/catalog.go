@@ -0,0 +1,25 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-clean-template/internal/domain"
+	"io"
+)
+
+// writeErrorCatalog writes the error code catalog, client teams generate their constants from it:
+//
+//	app errors-catalog > errors.json
+func writeErrorCatalog(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(map[string]any{
+		"locales": domain.Locales(),
+		"codes":   domain.Catalog(),
+	})
+	if err != nil {
+		return fmt.Errorf("enc.Encode: %w", err)
+	}
+	return nil
+}
@@ -15,6 +15,14 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "errors-catalog" {
+		err := writeErrorCatalog(os.Stdout)
+		if err != nil {
+			log.Fatal(fmt.Errorf("writeErrorCatalog: %w", err))
+		}
+		return
+	}
+
 	cfg, err := config.Load("./config/config.yml")
 	if err != nil {
 		log.Fatal(fmt.Errorf("config.Load: %w", err))
@@ -26,6 +34,14 @@ func main() {
 
 	mon := monitoring.New(cfg.PromPrefix)
 
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		err = runBackfill(cfg, mon, lg, os.Args[2:])
+		if err != nil {
+			lg.Fatal(fmt.Errorf("runBackfill: %w", err))
+		}
+		return
+	}
+
 	application, err := app.New(cfg, mon, lg)
 	if err != nil {
 		lg.Fatal(fmt.Errorf("app.New: %w", err))
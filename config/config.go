@@ -22,11 +22,16 @@ type Config struct {
 	InstanceID   uuid.UUID  `yaml:"instance-id" json:"instance_id"`
 	Logger       Logger     `yaml:"logger"      json:"logger"`
 	ConfigString string     `yaml:"-"           json:"-"`
+	FileName     string     `yaml:"-"           json:"-"`
 	DB           DB         `yaml:"db"          json:"db"`
 	HTTP         HTTP       `yaml:"http"        json:"http"`
+	Admin        Admin      `yaml:"admin"       json:"admin"`
+	Policy       Policy     `yaml:"policy"      json:"policy"`
 	Schedules    Schedules  `yaml:"schedules"   json:"schedules"`
 	HTTPClient   HTTPClient `yaml:"http-client" json:"http_client"`
 	API          API        `yaml:"api"         json:"api"`
+	Backfill     Backfill   `yaml:"backfill"    json:"backfill"`
+	Outbox       Outbox     `yaml:"outbox"      json:"outbox"`
 }
 
 type HTTP struct {
@@ -34,6 +39,327 @@ type HTTP struct {
 	ReadTimeout  time.Duration `yaml:"read-timeout"  json:"read_timeout"`
 	WriteTimeout time.Duration `yaml:"write-timeout" json:"write_timeout"`
 	IdleTimeout  time.Duration `yaml:"idle-timeout"  json:"idle_timeout"`
+	Idempotency  Idempotency   `yaml:"idempotency"   json:"idempotency"`
+	TLS          TLS           `yaml:"tls"           json:"tls"`
+	Auth         Auth          `yaml:"auth"          json:"auth"`
+	RateLimit    RateLimit     `yaml:"rate-limit"    json:"rate_limit"`
+	Admission    Admission     `yaml:"admission"     json:"admission"`
+	CORS         CORS          `yaml:"cors"          json:"cors"`
+	Logging      HTTPLogging   `yaml:"logging"       json:"logging"`
+	Compression  Compression   `yaml:"compression"   json:"compression"`
+	Limits       Limits        `yaml:"limits"        json:"limits"`
+	OpenAPI      OpenAPI       `yaml:"openapi"       json:"openapi"`
+	Versions     Versions      `yaml:"versions"      json:"versions"`
+	Cache        Cache         `yaml:"cache"         json:"cache"`
+	Lists        Lists         `yaml:"lists"         json:"lists"`
+	Streams      Streams       `yaml:"streams"       json:"streams"`
+	// Listeners replace the TCP listener on Port if set
+	Listeners []Listener `yaml:"listeners" json:"listeners"`
+}
+
+// Auth is checked for routes that are not registered as public
+type Auth struct {
+	Enabled bool    `yaml:"enabled"  json:"enabled"  env:"auth_enabled"`
+	JWT     JWT     `yaml:"jwt"      json:"jwt"`
+	APIKeys APIKeys `yaml:"api-keys" json:"api_keys"`
+}
+
+type JWT struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Algorithms accepted in the token header, HS256 needs HMACSecret, RS256 and ES256 need JWKSFile
+	Algorithms  []string      `yaml:"algorithms"   json:"algorithms"`
+	HMACSecret  string        `yaml:"hmac-secret"  json:"-"            env:"jwt_hmac_secret"`
+	JWKSFile    string        `yaml:"jwks-file"    json:"jwks_file"    env:"jwt_jwks_file"`
+	JWKSRefresh time.Duration `yaml:"jwks-refresh" json:"jwks_refresh"`
+	Issuer      string        `yaml:"issuer"       json:"issuer"`
+	Audience    string        `yaml:"audience"     json:"audience"`
+	Leeway      time.Duration `yaml:"leeway"       json:"leeway"`
+	// ScopeClaim holds space separated scopes or an array of them
+	ScopeClaim string `yaml:"scope-claim" json:"scope_claim"`
+}
+
+type APIKeys struct {
+	Enabled bool   `yaml:"enabled" json:"enabled"`
+	Header  string `yaml:"header"  json:"header"`
+	// Postgres looks up keys that are not in Static in the api_keys table
+	Postgres bool           `yaml:"postgres" json:"postgres"`
+	Static   []StaticAPIKey `yaml:"static"   json:"static"`
+}
+
+// StaticAPIKey stores only the hex SHA-256 hash of the key: echo -n "$KEY" | sha256sum
+type StaticAPIKey struct {
+	ID      string   `yaml:"id"      json:"id"`
+	Hash    string   `yaml:"hash"    json:"-"`
+	Subject string   `yaml:"subject" json:"subject"`
+	Scopes  []string `yaml:"scopes"  json:"scopes"`
+}
+
+// RateLimit takes a token from the bucket of the client on every request, routes without a limit use Default
+type RateLimit struct {
+	Enabled bool `yaml:"enabled" json:"enabled" env:"rate_limit_enabled"`
+	// Store is memory or postgres, postgres shares the buckets between replicas
+	Store string `yaml:"store" json:"store" env:"rate_limit_store"`
+	// Key is one of ip, api-key, principal. api-key and principal fall back to ip for anonymous requests
+	Key string `yaml:"key" json:"key"`
+	// TrustForwardedFor takes the client ip from X-Forwarded-For, enable it only behind a proxy that sets it.
+	// The list is walked from the right over TrustedProxies, the first other address is the client. Without
+	// TrustedProxies the rightmost entry, the one the proxy appended, is the client.
+	TrustForwardedFor bool `yaml:"trust-forwarded-for" json:"trust_forwarded_for"`
+	// TrustedProxies are CIDRs of the proxies in front of the service, X-Forwarded-For of other peers is ignored
+	TrustedProxies []string         `yaml:"trusted-proxies" json:"trusted_proxies"`
+	Default        RateLimitRule    `yaml:"default"         json:"default"`
+	Routes         []RouteRateLimit `yaml:"routes"          json:"routes"`
+}
+
+// RateLimitRule allows Requests per period, Burst defaults to Requests. Zero Requests means no limit
+type RateLimitRule struct {
+	Requests int           `yaml:"requests" json:"requests"`
+	Per      time.Duration `yaml:"per"      json:"per"`
+	Burst    int           `yaml:"burst"    json:"burst"`
+}
+
+// RouteRateLimit path is the route template, Key overrides the key of RateLimit
+type RouteRateLimit struct {
+	Method        string `yaml:"method" json:"method"`
+	Path          string `yaml:"path"   json:"path"`
+	Key           string `yaml:"key"    json:"key"`
+	RateLimitRule `yaml:",inline"`
+}
+
+// CORS applies to the public listener, Routes replace the rule for paths starting with their prefix.
+// It is reloaded with the config.
+type CORS struct {
+	Enabled  bool `yaml:"enabled" json:"enabled" env:"cors_enabled"`
+	CORSRule `yaml:",inline"`
+	Routes   []CORSRoute `yaml:"routes" json:"routes"`
+}
+
+type CORSRule struct {
+	// AllowedOrigins may contain one wildcard like https://*.example.com, "*" can't be used with credentials
+	AllowedOrigins   []string      `yaml:"allowed-origins"   json:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed-methods"   json:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed-headers"   json:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed-headers"   json:"exposed_headers"`
+	MaxAge           time.Duration `yaml:"max-age"           json:"max_age"`
+	AllowCredentials bool          `yaml:"allow-credentials" json:"allow_credentials"`
+}
+
+type CORSRoute struct {
+	Prefix   string `yaml:"prefix" json:"prefix"`
+	CORSRule `yaml:",inline"`
+}
+
+// HTTPLogging is the policy of the request logger, it is reloaded with the config.
+// Error responses are always logged, successful ones are sampled.
+type HTTPLogging struct {
+	// Body is on or off, empty means on
+	Body    string `yaml:"body"    json:"body"`
+	Headers bool   `yaml:"headers" json:"headers"`
+	// MaxBodyBytes caps the logged part of bodies, zero means 4 KiB
+	MaxBodyBytes int `yaml:"max-body-bytes" json:"max_body_bytes"`
+	// RedactHeaders and RedactFields are case insensitive, fields are JSON object keys at any depth
+	RedactHeaders []string `yaml:"redact-headers" json:"redact_headers"`
+	RedactFields  []string `yaml:"redact-fields"  json:"redact_fields"`
+	// SkipContentTypes are logged by size only, image/* matches every image type
+	SkipContentTypes []string `yaml:"skip-content-types" json:"skip_content_types"`
+	// SampleRate is the share of successful requests that are logged, zero means all and negative none
+	SampleRate float64        `yaml:"sample-rate" json:"sample_rate"`
+	Routes     []LoggingRoute `yaml:"routes"      json:"routes"`
+}
+
+// LoggingRoute path is the route template, empty Body and zero SampleRate keep the defaults of HTTPLogging
+type LoggingRoute struct {
+	Method     string  `yaml:"method"      json:"method"`
+	Path       string  `yaml:"path"        json:"path"`
+	Body       string  `yaml:"body"        json:"body"`
+	SampleRate float64 `yaml:"sample-rate" json:"sample_rate"`
+}
+
+// Lists sign the cursors of list endpoints, instances behind one address need the same secret.
+// A random secret is used if it is empty, cursors are then valid only until restart.
+type Lists struct {
+	CursorSecret string `yaml:"cursor-secret" json:"-" env:"list_cursor_secret"`
+}
+
+// Streams are Server-Sent Events, every stream keeps the last Replay events for clients that resume
+// with Last-Event-ID
+type Streams struct {
+	Heartbeat time.Duration `yaml:"heartbeat" json:"heartbeat"`
+	// Retry is sent to clients as the reconnection delay
+	Retry  time.Duration `yaml:"retry"  json:"retry"`
+	Replay int           `yaml:"replay" json:"replay"`
+	// ClientBuffer is the number of events queued for a connection, a client that falls further behind
+	// is disconnected and resumes from the replay buffer
+	ClientBuffer int `yaml:"client-buffer" json:"client_buffer"`
+}
+
+// Cache adds ETags to GET responses and answers conditional requests with 304 Not Modified.
+// Cache-Control and the server cache are set per route.
+type Cache struct {
+	Enabled bool `yaml:"enabled" json:"enabled" env:"http_cache_enabled"`
+	// WeakETags are computed for responses that are equal in meaning, not byte for byte
+	WeakETags bool `yaml:"weak-etags" json:"weak_etags"`
+	// Server keeps responses of routes registered with a TTL in memory
+	Server bool `yaml:"server" json:"server" env:"http_server_cache"`
+	// MaxEntries of the server cache, responses are not stored if it is full
+	MaxEntries int `yaml:"max-entries" json:"max_entries"`
+	// MaxBodySize of stored and hashed responses, larger responses are sent without ETag
+	MaxBodySize int `yaml:"max-body-size" json:"max_body_size"`
+}
+
+// Versions of the public API are chosen by the path /api/v1/... or by Accept for paths without a version:
+// application/vnd.<app>.v1+json or application/json; version=1
+type Versions struct {
+	// Default serves paths without a version when Accept names none, empty requires a version
+	Default string `yaml:"default" json:"default" env:"api_default_version"`
+	// Lifecycle holds the dates of deprecated versions, versions that are not listed are current
+	Lifecycle []APIVersion `yaml:"lifecycle" json:"lifecycle"`
+}
+
+// APIVersion responses carry Deprecation and Sunset headers, after Sunset the version answers 410 Gone
+type APIVersion struct {
+	Name       string    `yaml:"name"       json:"name"`
+	Deprecated time.Time `yaml:"deprecated" json:"deprecated"`
+	Sunset     time.Time `yaml:"sunset"     json:"sunset"`
+	// Link is the migration guide, sent as Link with rel="deprecation"
+	Link string `yaml:"link" json:"link"`
+}
+
+// OpenAPI serves the spec of registered routes at /openapi.json and Swagger UI at /docs.
+// Validate rejects requests that don't match the spec, in LOCAL environment responses are checked too.
+type OpenAPI struct {
+	Enabled  bool `yaml:"enabled"  json:"enabled"  env:"openapi_enabled"`
+	Validate bool `yaml:"validate" json:"validate" env:"openapi_validate"`
+}
+
+// Limits are the defaults of route options, zero means no limit
+type Limits struct {
+	// MaxBodySize in bytes, larger requests get 413
+	MaxBodySize int64 `yaml:"max-body-size" json:"max_body_size"`
+	// Timeout is the deadline of handlers, the client gets 503 when it passes even if the handler goes on.
+	// Responses are buffered to be replaced by the 503, streaming routes turn the timeout off.
+	Timeout time.Duration `yaml:"timeout" json:"timeout"`
+	// BodyReadTimeout replaces ReadTimeout for the body of slow clients, they get 408
+	BodyReadTimeout time.Duration `yaml:"body-read-timeout" json:"body_read_timeout"`
+}
+
+// Compression encodes responses of ContentTypes once they reach MinSize bytes
+type Compression struct {
+	Enabled bool `yaml:"enabled" json:"enabled" env:"compression_enabled"`
+	// Encodings are zstd, gzip and deflate, the first one wins when the client accepts several equally
+	Encodings []string `yaml:"encodings" json:"encodings"`
+	MinSize   int      `yaml:"min-size"  json:"min_size"`
+	// ContentTypes may end with a wildcard like text/*
+	ContentTypes []string `yaml:"content-types" json:"content_types"`
+	// Level is from 1 (fastest) to 9 (smallest), zero is the default of each encoding
+	Level int `yaml:"level" json:"level"`
+}
+
+// Admission queues requests over the concurrency limit of their route group, the limit follows latency (AIMD)
+type Admission struct {
+	Enabled bool             `yaml:"enabled" json:"enabled" env:"admission_enabled"`
+	Groups  []AdmissionGroup `yaml:"groups"  json:"groups"`
+}
+
+// AdmissionGroup takes requests whose path starts with one of Prefixes, the first matching group is used
+// and requests that match no group are not limited
+type AdmissionGroup struct {
+	Name         string   `yaml:"name"          json:"name"`
+	Prefixes     []string `yaml:"prefixes"      json:"prefixes"`
+	InitialLimit int      `yaml:"initial-limit" json:"initial_limit"`
+	MinLimit     int      `yaml:"min-limit"     json:"min_limit"`
+	MaxLimit     int      `yaml:"max-limit"     json:"max_limit"`
+	// TargetLatency is the latency above which the limit is multiplied by Backoff
+	TargetLatency time.Duration `yaml:"target-latency" json:"target_latency"`
+	Backoff       float64       `yaml:"backoff"        json:"backoff"`
+	MaxQueue      int           `yaml:"max-queue"      json:"max_queue"`
+	QueueTimeout  time.Duration `yaml:"queue-timeout"  json:"queue_timeout"`
+	// Routes set priorities, other requests are normal if authenticated and low otherwise
+	Routes []AdmissionRoute `yaml:"routes" json:"routes"`
+}
+
+// AdmissionRoute path is the route template, Priority is one of low, normal, high
+type AdmissionRoute struct {
+	Method   string `yaml:"method"   json:"method"`
+	Path     string `yaml:"path"     json:"path"`
+	Priority string `yaml:"priority" json:"priority"`
+}
+
+// Policy maps principals to roles and routes to permissions, it is checked after authentication
+type Policy struct {
+	Enabled bool `yaml:"enabled" json:"enabled" env:"policy_enabled"`
+	// Postgres adds roles, bindings and routes of the rbac_* tables to the ones in config
+	Postgres        bool            `yaml:"postgres"         json:"postgres"`
+	RefreshInterval time.Duration   `yaml:"refresh-interval" json:"refresh_interval"`
+	Roles           []PolicyRole    `yaml:"roles"            json:"roles"`
+	Bindings        []PolicyBinding `yaml:"bindings"         json:"bindings"`
+	Routes          []PolicyRoute   `yaml:"routes"           json:"routes"`
+}
+
+type PolicyRole struct {
+	Name        string   `yaml:"name"        json:"name"`
+	Permissions []string `yaml:"permissions" json:"permissions"`
+}
+
+// PolicyBinding subject is prefixed with the auth method like jwt:alice, api_key:ci or client_cert:ops,
+// or is * for everyone. Bindings of bare subjects are ignored.
+type PolicyBinding struct {
+	Subject string   `yaml:"subject" json:"subject"`
+	Roles   []string `yaml:"roles"   json:"roles"`
+}
+
+// PolicyRoute path is the route template like /api/v1/jobs/{name}/trigger
+type PolicyRoute struct {
+	Method     string `yaml:"method"     json:"method"`
+	Path       string `yaml:"path"       json:"path"`
+	Permission string `yaml:"permission" json:"permission"`
+}
+
+type Listener struct {
+	// Network is one of tcp, unix, systemd
+	Network string `yaml:"network" json:"network"`
+	// Address is host:port for tcp, socket path for unix and FileDescriptorName for systemd (empty takes all)
+	Address string `yaml:"address" json:"address"`
+	// SocketMode is the octal file mode of a unix socket
+	SocketMode string `yaml:"socket-mode" json:"socket_mode"`
+	// H2C serves HTTP/2 over cleartext, it can't be combined with TLS
+	H2C bool `yaml:"h2c" json:"h2c"`
+	TLS bool `yaml:"tls" json:"tls"`
+}
+
+type TLS struct {
+	Enabled  bool   `yaml:"enabled"   json:"enabled"   env:"tls_enabled"`
+	CertFile string `yaml:"cert-file" json:"cert_file" env:"tls_cert_file"`
+	KeyFile  string `yaml:"key-file"  json:"key_file"  env:"tls_key_file"`
+	// MinVersion is one of 1.0, 1.1, 1.2, 1.3
+	MinVersion string `yaml:"min-version" json:"min_version"`
+	// CipherSuites are names from crypto/tls, empty means Go defaults. TLS 1.3 suites are not configurable
+	CipherSuites []string `yaml:"cipher-suites" json:"cipher_suites"`
+	// ClientAuth is one of none, request, require, verify-if-given, require-and-verify
+	ClientAuth     string        `yaml:"client-auth"     json:"client_auth"`
+	ClientCAFile   string        `yaml:"client-ca-file"  json:"client_ca_file"  env:"tls_client_ca_file"`
+	ReloadInterval time.Duration `yaml:"reload-interval" json:"reload_interval"`
+}
+
+type Idempotency struct {
+	Enabled     bool          `yaml:"enabled"      json:"enabled"      env:"idempotency_enabled"`
+	TTL         time.Duration `yaml:"ttl"          json:"ttl"`
+	LockTimeout time.Duration `yaml:"lock-timeout" json:"lock_timeout"`
+}
+
+// Admin is the listener of pprof, metrics, probes and admin actions, it must not be exposed publicly
+type Admin struct {
+	Port         string        `yaml:"port"          json:"port"          env:"admin_server_port"`
+	ReadTimeout  time.Duration `yaml:"read-timeout"  json:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write-timeout" json:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle-timeout"  json:"idle_timeout"`
+	// TLS of the admin listener, with client-auth set to verify the certificate names the actor of admin actions
+	TLS TLS `yaml:"tls" json:"tls" env-prefix:"admin_"`
+	// ActorHeader names the header with the operator of admin actions. It is not verified, it must be set by
+	// the proxy in front of the listener. A verified client certificate takes precedence over it.
+	ActorHeader string `yaml:"actor-header" json:"actor_header"`
+	// RequireActor rejects state-changing admin requests without an actor
+	RequireActor bool `yaml:"require-actor" json:"require_actor"`
 }
 
 type DB struct {
@@ -51,10 +377,32 @@ type DB struct {
 	MaxOpenConns    int           `yaml:"max-open-conns"    json:"max_open_conns"`
 	ConnMaxLifetime time.Duration `yaml:"conn-max-lifetime" json:"conn_max_lifetime"`
 	SSLMode         bool          `yaml:"ssl-mode"          json:"ssl_mode"`
+	// AnnotateQueries appends the request ID to statements. Annotated statements skip the statement cache,
+	// every one costs an extra round trip to describe it.
+	AnnotateQueries bool `yaml:"annotate-queries" json:"annotate_queries"`
 }
 
 type Schedules struct {
-	Persist string `yaml:"persist" json:"persist" env:"persist-schedule"`
+	Persist          string `yaml:"persist"           json:"persist"           env:"persist-schedule"`
+	IdempotencyPurge string `yaml:"idempotency-purge" json:"idempotency_purge"`
+	RateLimitPurge   string `yaml:"rate-limit-purge"  json:"rate_limit_purge"`
+	OutboxPurge      string `yaml:"outbox-purge"      json:"outbox_purge"`
+}
+
+type Backfill struct {
+	Concurrency int `yaml:"concurrency" json:"concurrency" env:"backfill_concurrency"`
+	MaxDays     int `yaml:"max-days"    json:"max_days"`
+	// LockTTL is the lease of a run in the database, the instance running it renews the lease,
+	// a run with an expired lease was interrupted and can be taken over
+	LockTTL time.Duration `yaml:"lock-ttl" json:"lock_ttl"`
+}
+
+// Outbox rows are relayed to every instance in batches of BatchSize every Interval, published rows are
+// purged after Retention
+type Outbox struct {
+	Interval  time.Duration `yaml:"interval"   json:"interval"`
+	BatchSize int           `yaml:"batch-size" json:"batch_size"`
+	Retention time.Duration `yaml:"retention"  json:"retention"`
 }
 
 type HTTPClient struct {
@@ -109,6 +457,11 @@ func Load(fileName string) (*Config, error) {
 		out = []byte("config marshal error: " + err.Error())
 	}
 	cfg.ConfigString = string(out)
+	cfg.FileName = fileName
+
+	if cfg.InstanceID == uuid.Nil {
+		cfg.InstanceID = uuid.New()
+	}
 
 	err = cleanenv.ReadEnv(&cfg)
 	if err != nil {
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE if not exists schema_.backfill_runs (
+    id UUID NOT NULL,
+    date_from DATE NOT NULL,
+    date_to DATE NOT NULL,
+    status TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (id)
+);
+CREATE INDEX if not exists backfill_runs_range_idx ON schema_.backfill_runs (date_from, date_to);
+
+CREATE TABLE if not exists schema_.backfill_days (
+    run_id UUID NOT NULL REFERENCES schema_.backfill_runs (id) ON DELETE CASCADE,
+    dt DATE NOT NULL,
+    status TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    PRIMARY KEY (run_id, dt)
+);
+
+
+-- +goose Down
+--DROP TABLE schema_.backfill_days;
+--DROP TABLE schema_.backfill_runs;
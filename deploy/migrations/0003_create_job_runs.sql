@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE if not exists schema_.job_runs (
+    id UUID NOT NULL,
+    job_name TEXT NOT NULL,
+    instance_id UUID NOT NULL,
+    triggered_by TEXT NOT NULL,
+    status TEXT NOT NULL,
+    params JSONB NOT NULL DEFAULT '{}',
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ,
+    PRIMARY KEY (id)
+);
+CREATE INDEX if not exists job_runs_name_started_idx ON schema_.job_runs (job_name, started_at DESC);
+
+
+-- +goose Down
+--DROP TABLE schema_.job_runs;
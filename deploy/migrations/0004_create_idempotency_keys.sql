@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE if not exists schema_.idempotency_keys (
+    key TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    state TEXT NOT NULL,
+    response_status INT NOT NULL DEFAULT 0,
+    response_headers JSONB NOT NULL DEFAULT '{}',
+    response_body BYTEA,
+    locked_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (key)
+);
+CREATE INDEX if not exists idempotency_keys_expires_at_idx ON schema_.idempotency_keys (expires_at);
+
+
+-- +goose Down
+--DROP TABLE schema_.idempotency_keys;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE if not exists schema_.outbox (
+    id UUID NOT NULL,
+    event_name TEXT NOT NULL,
+    event_key TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    published_at TIMESTAMPTZ,
+    PRIMARY KEY (id)
+);
+CREATE INDEX if not exists outbox_unpublished_idx ON schema_.outbox (created_at) WHERE published_at IS NULL;
+
+
+-- +goose Down
+--DROP TABLE schema_.outbox;
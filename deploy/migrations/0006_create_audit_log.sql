@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE if not exists schema_.audit_log (
+    id UUID NOT NULL,
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    method TEXT NOT NULL DEFAULT '',
+    route TEXT NOT NULL DEFAULT '',
+    resource_id TEXT NOT NULL DEFAULT '',
+    request_hash TEXT NOT NULL DEFAULT '',
+    response_hash TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT '',
+    status INT NOT NULL DEFAULT 0,
+    outcome TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (id)
+);
+CREATE INDEX if not exists audit_log_created_idx ON schema_.audit_log (created_at DESC);
+CREATE INDEX if not exists audit_log_actor_created_idx ON schema_.audit_log (actor, created_at DESC);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION schema_.audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP TRIGGER if exists audit_log_append_only ON schema_.audit_log;
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON schema_.audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION schema_.audit_log_append_only();
+
+
+-- +goose Down
+--DROP TABLE schema_.audit_log;
+--DROP FUNCTION schema_.audit_log_append_only;
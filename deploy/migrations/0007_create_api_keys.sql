@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE if not exists schema_.api_keys (
+    id TEXT NOT NULL,
+    key_hash TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX if not exists api_keys_key_hash_idx ON schema_.api_keys (key_hash);
+
+
+-- +goose Down
+--DROP TABLE schema_.api_keys;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE if not exists schema_.rbac_roles (
+    name TEXT NOT NULL,
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    PRIMARY KEY (name)
+);
+
+CREATE TABLE if not exists schema_.rbac_bindings (
+    subject TEXT NOT NULL,
+    role TEXT NOT NULL,
+    PRIMARY KEY (subject, role)
+);
+
+CREATE TABLE if not exists schema_.rbac_routes (
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    permission TEXT NOT NULL,
+    PRIMARY KEY (method, path)
+);
+
+
+-- +goose Down
+--DROP TABLE schema_.rbac_routes;
+--DROP TABLE schema_.rbac_bindings;
+--DROP TABLE schema_.rbac_roles;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE if not exists schema_.rate_limits (
+    key TEXT NOT NULL,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (key)
+);
+CREATE INDEX if not exists rate_limits_expires_at_idx ON schema_.rate_limits (expires_at);
+
+
+-- +goose Down
+--DROP TABLE schema_.rate_limits;
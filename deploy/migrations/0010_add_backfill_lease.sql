@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE schema_.backfill_runs ADD COLUMN if not exists locked_by UUID;
+ALTER TABLE schema_.backfill_runs ADD COLUMN if not exists locked_until TIMESTAMPTZ;
+
+
+-- +goose Down
+--ALTER TABLE schema_.backfill_runs DROP COLUMN locked_until;
+--ALTER TABLE schema_.backfill_runs DROP COLUMN locked_by;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- request_hash and response_hash are kept for the entries recorded before, the log is append-only
+ALTER TABLE schema_.audit_log ADD COLUMN if not exists before_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE schema_.audit_log ADD COLUMN if not exists after_hash TEXT NOT NULL DEFAULT '';
+
+
+-- +goose Down
+--ALTER TABLE schema_.audit_log DROP COLUMN after_hash;
+--ALTER TABLE schema_.audit_log DROP COLUMN before_hash;
@@ -0,0 +1,6 @@
+-- +goose Up
+CREATE INDEX if not exists outbox_published_idx ON schema_.outbox (published_at) WHERE published_at IS NOT NULL;
+
+
+-- +goose Down
+--DROP INDEX schema_.outbox_published_idx;
@@ -16,11 +16,12 @@ import (
 )
 
 type app struct {
-	prov       Provider
-	httpServer Facade
-	cronJob    Facade
-	mon        monitoring.Monitoring
-	lg         logger.Logger
+	prov        Provider
+	httpServer  Facade
+	adminServer Facade
+	cronJob     Facade
+	mon         monitoring.Monitoring
+	lg          logger.Logger
 }
 
 type Provider interface {
@@ -43,13 +44,22 @@ func New(cfg *config.Config, mon monitoring.Monitoring, lg logger.Logger) (*app,
 		return nil, fmt.Errorf("provider.New: %w", err)
 	}
 
-	httpServer := httpserver.New(cfg.HTTP, prov)
+	httpServer, err := httpserver.New(cfg.HTTP, prov)
+	if err != nil {
+		return nil, fmt.Errorf("httpserver.New: %w", err)
+	}
+
+	adminServer, err := httpserver.NewAdmin(cfg.Admin, prov)
+	if err != nil {
+		return nil, fmt.Errorf("httpserver.NewAdmin: %w", err)
+	}
 
 	cronJob := cron.New(cfg.Schedules, prov)
 
 	return &app{
 		prov,
 		httpServer,
+		adminServer,
 		cronJob,
 		mon,
 		lg,
@@ -57,7 +67,8 @@ func New(cfg *config.Config, mon monitoring.Monitoring, lg logger.Logger) (*app,
 }
 
 func (a *app) Run(ctx context.Context) error {
-	errChan := make(chan error)
+	// every runner can send after Run has returned, so none of them blocks
+	errChan := make(chan error, 3)
 
 	go func() {
 		err := a.httpServer.Run(ctx)
@@ -69,6 +80,16 @@ func (a *app) Run(ctx context.Context) error {
 	}()
 	a.lg.Info("http server started at port", a.httpServer.Info())
 
+	go func() {
+		err := a.adminServer.Run(ctx)
+		if err != nil {
+			a.lg.Error("adminServer.Run:", err)
+		}
+		a.lg.Info("admin server stopped")
+		errChan <- err
+	}()
+	a.lg.Info("admin server started at port", a.adminServer.Info())
+
 	go func() {
 		err := a.cronJob.Run(ctx)
 		if err != nil {
@@ -93,6 +114,14 @@ func (a *app) Stop(ctx context.Context) error {
 		return nil
 	})
 
+	eg.Go(func() error {
+		err := a.adminServer.Stop(ctx)
+		if err != nil {
+			return fmt.Errorf("a.adminServer.Stop: %w", err)
+		}
+		return nil
+	})
+
 	eg.Go(func() error {
 		err := a.cronJob.Stop(ctx)
 		if err != nil {
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-clean-template/pkg/listquery"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditActionRequest      = "http.request"
+	AuditActionJobTrigger   = "job.trigger"
+	AuditActionConfigReload = "config.reload"
+
+	// ActorSystem is the actor of actions that are not caused by a request
+	ActorSystem = "system"
+)
+
+type AuditOutcome string
+
+const (
+	AuditOutcomeSuccess AuditOutcome = "success"
+	AuditOutcomeFailure AuditOutcome = "failure"
+)
+
+type AuditEntry struct {
+	ID         uuid.UUID `json:"id"`
+	Actor      string    `json:"actor"`
+	Action     string    `json:"action"`
+	Method     string    `json:"method,omitempty"`
+	Route      string    `json:"route,omitempty"`
+	ResourceID string    `json:"resource_id,omitempty"`
+	// BeforeHash and AfterHash are SHA-256 of the JSON of the resource state before and after the action
+	BeforeHash string       `json:"before_hash,omitempty"`
+	AfterHash  string       `json:"after_hash,omitempty"`
+	RequestID  string       `json:"request_id,omitempty"`
+	Status     int          `json:"status,omitempty"`
+	Outcome    AuditOutcome `json:"outcome"`
+	Error      string       `json:"error,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+//nolint:gochecknoglobals //fields of the audit list
+var AuditList = listquery.Spec{
+	Fields: map[string]listquery.Field{
+		"id":          {Column: "id", Type: listquery.UUID, Filter: true, Sort: true},
+		"actor":       {Column: "actor", Filter: true},
+		"action":      {Column: "action", Filter: true},
+		"method":      {Column: "method", Filter: true},
+		"route":       {Column: "route", Filter: true},
+		"resource_id": {Column: "resource_id", Filter: true},
+		"request_id":  {Column: "request_id", Filter: true},
+		"status":      {Column: "status", Type: listquery.Int, Filter: true, Sort: true},
+		"outcome": {Column: "outcome", Filter: true,
+			Values: []string{string(AuditOutcomeSuccess), string(AuditOutcomeFailure)}},
+		"created_at": {Column: "created_at", Type: listquery.Time, Filter: true, Sort: true},
+	},
+	Key:          "id",
+	Sort:         "-created_at",
+	DefaultLimit: 100,
+	MaxLimit:     1000,
+}
+
+// ListValue returns the value of a field of AuditList
+func (e AuditEntry) ListValue(field string) any {
+	switch field {
+	case "id":
+		return e.ID
+	case "status":
+		return e.Status
+	case "created_at":
+		return e.CreatedAt
+	}
+	return nil
+}
+
+// StateHash returns the hex SHA-256 of the JSON of a resource state, a nil state has no hash
+func StateHash(state any) (string, error) {
+	if state == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("domain.StateHash: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+type AuditService interface {
+	// Record appends the entry, ID and CreatedAt are set if empty
+	Record(ctx context.Context, entry AuditEntry) error
+	// List returns a page of entries with one more entry if there is a next page
+	List(ctx context.Context, q listquery.Query) ([]AuditEntry, error)
+}
+
+type actorKey struct{}
+
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by ContextWithActor or ActorSystem
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return ActorSystem
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+const (
+	ScopeDataRead = "data:read"
+	ScopeJobsRead = "jobs:read"
+)
+
+const (
+	AuthMethodJWT        = "jwt"
+	AuthMethodAPIKey     = "api_key"
+	AuthMethodClientCert = "client_cert"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Subject string   `json:"subject"`
+	Method  string   `json:"method"`
+	Scopes  []string `json:"scopes"`
+	// KeyID is the id of the API key or of the JWT signing key
+	KeyID string `json:"key_id,omitempty"`
+}
+
+// HasScopes reports whether the principal has every scope
+func (p Principal) HasScopes(scopes ...string) bool {
+	for _, s := range scopes {
+		if !slices.Contains(p.Scopes, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// Actor is the audit actor of the principal
+func (p Principal) Actor() string {
+	return p.Method + ":" + p.Subject
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal also sets the principal as the audit actor
+func ContextWithPrincipal(ctx context.Context, p Principal) context.Context {
+	return ContextWithActor(context.WithValue(ctx, principalKey{}, p), p.Actor())
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+type APIKey struct {
+	ID        string     `json:"id"`
+	Subject   string     `json:"subject"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type AuthService interface {
+	// AuthenticateBearer verifies a JWT bearer token
+	AuthenticateBearer(ctx context.Context, token string) (Principal, error)
+	// AuthenticateAPIKey looks the key up by its hash
+	AuthenticateAPIKey(ctx context.Context, key string) (Principal, error)
+}
+
+// ClientCert describes the TLS client certificate of the request
+type ClientCert struct {
+	Subject      string   `json:"subject"`
+	CommonName   string   `json:"common_name"`
+	Issuer       string   `json:"issuer"`
+	SerialNumber string   `json:"serial_number"`
+	DNSNames     []string `json:"dns_names,omitempty"`
+	// Verified is false if the server accepts certificates without verifying them against the CA bundle
+	Verified bool `json:"verified"`
+}
+
+type clientCertKey struct{}
+
+func ContextWithClientCert(ctx context.Context, cert ClientCert) context.Context {
+	return context.WithValue(ctx, clientCertKey{}, cert)
+}
+
+func ClientCertFromContext(ctx context.Context) (ClientCert, bool) {
+	cert, ok := ctx.Value(clientCertKey{}).(ClientCert)
+	return cert, ok
+}
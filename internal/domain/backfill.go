@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type BackfillStatus string
+
+const (
+	BackfillStatusPending BackfillStatus = "pending"
+	BackfillStatusRunning BackfillStatus = "running"
+	BackfillStatusStopped BackfillStatus = "stopped"
+	BackfillStatusDone    BackfillStatus = "done"
+	BackfillStatusFailed  BackfillStatus = "failed"
+)
+
+type BackfillRun struct {
+	ID        uuid.UUID      `json:"id"`
+	DateFrom  string         `json:"date_from"`
+	DateTo    string         `json:"date_to"`
+	Status    BackfillStatus `json:"status"`
+	Total     int            `json:"total"`
+	Done      int            `json:"done"`
+	Failed    int            `json:"failed"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type BackfillDay struct {
+	Date       string         `json:"date"`
+	Status     BackfillStatus `json:"status"`
+	Attempts   int            `json:"attempts"`
+	Error      string         `json:"error,omitempty"`
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+}
+
+type BackfillService interface {
+	// Create registers a run for the range or returns an unfinished run for the same range
+	Create(ctx context.Context, from, to string) (BackfillRun, error)
+	// Run persists every day of the run that is not done yet and blocks until it finishes or ctx is done
+	Run(ctx context.Context, id uuid.UUID) (BackfillRun, error)
+	// Start runs in the background until the run finishes or the service is closed
+	Start(ctx context.Context, id uuid.UUID) error
+	Get(ctx context.Context, id uuid.UUID) (BackfillRun, []BackfillDay, error)
+	List(ctx context.Context, limit int) ([]BackfillRun, error)
+	// Close stops background runs and waits for them
+	Close(ctx context.Context) error
+}
@@ -0,0 +1,262 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+)
+
+// ErrorCode is a stable machine-readable error identifier, clients may branch on it.
+// Codes are never renamed or reused, messages may change.
+type ErrorCode string
+
+const (
+	CodeInternal         ErrorCode = "INTERNAL_ERROR"
+	CodeDataNotFound     ErrorCode = "DATA_NOT_FOUND"
+	CodeAlreadyProcessed ErrorCode = "ALREADY_PROCESSED"
+	CodeValidationFailed ErrorCode = "VALIDATION_FAILED"
+	CodeRouteNotFound    ErrorCode = "ROUTE_NOT_FOUND"
+	CodeMethodNotAllowed ErrorCode = "METHOD_NOT_ALLOWED"
+	CodeInvalidPath      ErrorCode = "INVALID_PATH"
+	CodeInvalidBody      ErrorCode = "INVALID_BODY"
+	CodeBodyTooLarge     ErrorCode = "BODY_TOO_LARGE"
+	CodeBodyReadTimeout  ErrorCode = "BODY_READ_TIMEOUT"
+	CodeHandlerTimeout   ErrorCode = "HANDLER_TIMEOUT"
+	CodeVersionNotFound  ErrorCode = "API_VERSION_NOT_FOUND"
+	CodeVersionRetired   ErrorCode = "API_VERSION_RETIRED"
+
+	CodeFieldRequired     ErrorCode = "FIELD_REQUIRED"
+	CodeFieldTooSmall     ErrorCode = "FIELD_TOO_SMALL"
+	CodeFieldTooLarge     ErrorCode = "FIELD_TOO_LARGE"
+	CodeFieldNotAllowed   ErrorCode = "FIELD_NOT_ALLOWED"
+	CodeFieldInvalidDate  ErrorCode = "FIELD_INVALID_DATE"
+	CodeFieldInvalidFmt   ErrorCode = "FIELD_INVALID_FORMAT"
+	CodeFieldInvalidType  ErrorCode = "FIELD_INVALID_TYPE"
+	CodeFieldInvalidRange ErrorCode = "FIELD_INVALID_RANGE"
+
+	CodeJobNotFound          ErrorCode = "JOB_NOT_FOUND"
+	CodeBackfillRunNotFound  ErrorCode = "BACKFILL_RUN_NOT_FOUND"
+	CodeBackfillInProgress   ErrorCode = "BACKFILL_IN_PROGRESS"
+	CodeBackfillRangeTooLong ErrorCode = "BACKFILL_RANGE_TOO_LONG"
+
+	CodeAuthRequired        ErrorCode = "AUTH_REQUIRED"
+	CodeAuthInvalid         ErrorCode = "AUTH_INVALID_CREDENTIALS"
+	CodeAuthTokenExpired    ErrorCode = "AUTH_TOKEN_EXPIRED"
+	CodeAuthAPIKeyRevoked   ErrorCode = "AUTH_API_KEY_REVOKED"
+	CodeForbidden           ErrorCode = "FORBIDDEN"
+	CodeForbiddenNoScopes   ErrorCode = "FORBIDDEN_MISSING_SCOPES"
+	CodeForbiddenPermission ErrorCode = "FORBIDDEN_PERMISSION"
+
+	CodeRateLimited ErrorCode = "RATE_LIMITED"
+	CodeOverloaded  ErrorCode = "SERVICE_OVERLOADED"
+	CodeStopping    ErrorCode = "SERVICE_STOPPING"
+
+	CodeIdempotencyKeyReused  ErrorCode = "IDEMPOTENCY_KEY_REUSED"
+	CodeIdempotencyInProgress ErrorCode = "IDEMPOTENCY_REQUEST_IN_PROGRESS"
+)
+
+type Locale string
+
+const (
+	LocaleEN Locale = "en"
+	LocaleRU Locale = "ru"
+
+	DefaultLocale = LocaleEN
+)
+
+// Params are substituted into "{name}" placeholders of catalog messages
+type Params map[string]string
+
+// catalog holds message templates of every code, each code must have a message for DefaultLocale
+var catalog = map[ErrorCode]map[Locale]string{ //nolint:gochecknoglobals //error catalog
+	CodeInternal: {
+		LocaleEN: "Internal server error",
+		LocaleRU: "Внутренняя ошибка сервера",
+	},
+	CodeDataNotFound: {
+		LocaleEN: "Data not found",
+		LocaleRU: "Данные не найдены",
+	},
+	CodeAlreadyProcessed: {
+		LocaleEN: "Already processed",
+		LocaleRU: "Уже обработано",
+	},
+	CodeValidationFailed: {
+		LocaleEN: "Request validation failed",
+		LocaleRU: "Запрос не прошёл проверку",
+	},
+	CodeRouteNotFound: {
+		LocaleEN: "No route for {path}",
+		LocaleRU: "Маршрут {path} не найден",
+	},
+	CodeMethodNotAllowed: {
+		LocaleEN: "Method {method} is not allowed for {path}",
+		LocaleRU: "Метод {method} не разрешён для {path}",
+	},
+	CodeInvalidPath: {
+		LocaleEN: "Path must be a valid UTF-8 string",
+		LocaleRU: "Путь должен быть корректной строкой UTF-8",
+	},
+	CodeInvalidBody: {
+		LocaleEN: "Request body can't be read",
+		LocaleRU: "Не удалось прочитать тело запроса",
+	},
+	CodeBodyTooLarge: {
+		LocaleEN: "Request body must be at most {limit} bytes",
+		LocaleRU: "Тело запроса должно быть не больше {limit} байт",
+	},
+	CodeBodyReadTimeout: {
+		LocaleEN: "Request body was not received in time",
+		LocaleRU: "Тело запроса не получено вовремя",
+	},
+	CodeHandlerTimeout: {
+		LocaleEN: "Request was not handled in time",
+		LocaleRU: "Запрос не обработан вовремя",
+	},
+	CodeVersionNotFound: {
+		LocaleEN: "API version {version} is not supported",
+		LocaleRU: "Версия API {version} не поддерживается",
+	},
+	CodeVersionRetired: {
+		LocaleEN: "API version {version} was retired on {sunset}",
+		LocaleRU: "Версия API {version} отключена {sunset}",
+	},
+	CodeFieldRequired: {
+		LocaleEN: "{field} is required",
+		LocaleRU: "Поле {field} обязательно",
+	},
+	CodeFieldTooSmall: {
+		LocaleEN: "{field} must be at least {param}",
+		LocaleRU: "Поле {field} должно быть не меньше {param}",
+	},
+	CodeFieldTooLarge: {
+		LocaleEN: "{field} must be at most {param}",
+		LocaleRU: "Поле {field} должно быть не больше {param}",
+	},
+	CodeFieldNotAllowed: {
+		LocaleEN: "{field} must be one of {param}",
+		LocaleRU: "Поле {field} должно быть одним из {param}",
+	},
+	CodeFieldInvalidDate: {
+		LocaleEN: "{field} must be a date in format {param}",
+		LocaleRU: "Поле {field} должно быть датой в формате {param}",
+	},
+	CodeFieldInvalidFmt: {
+		LocaleEN: "{field} must match {param}",
+		LocaleRU: "Поле {field} должно соответствовать {param}",
+	},
+	CodeFieldInvalidType: {
+		LocaleEN: "{field} must be {param}",
+		LocaleRU: "Поле {field} должно иметь тип {param}",
+	},
+	CodeFieldInvalidRange: {
+		LocaleEN: "{field} must not be before {param}",
+		LocaleRU: "Поле {field} не должно быть раньше {param}",
+	},
+	CodeJobNotFound: {
+		LocaleEN: "Job {job} not found",
+		LocaleRU: "Задача {job} не найдена",
+	},
+	CodeBackfillRunNotFound: {
+		LocaleEN: "Backfill run not found",
+		LocaleRU: "Запуск дозагрузки не найден",
+	},
+	CodeBackfillInProgress: {
+		LocaleEN: "Backfill run {id} is already in progress",
+		LocaleRU: "Запуск дозагрузки {id} уже выполняется",
+	},
+	CodeBackfillRangeTooLong: {
+		LocaleEN: "Backfill range must not be longer than {max} days",
+		LocaleRU: "Период дозагрузки не должен превышать {max} дней",
+	},
+	CodeAuthRequired: {
+		LocaleEN: "Authentication is required",
+		LocaleRU: "Требуется аутентификация",
+	},
+	CodeAuthInvalid: {
+		LocaleEN: "Credentials are invalid",
+		LocaleRU: "Неверные учётные данные",
+	},
+	CodeAuthTokenExpired: {
+		LocaleEN: "Token has expired",
+		LocaleRU: "Срок действия токена истёк",
+	},
+	CodeAuthAPIKeyRevoked: {
+		LocaleEN: "API key {id} is revoked or expired",
+		LocaleRU: "API-ключ {id} отозван или просрочен",
+	},
+	CodeForbidden: {
+		LocaleEN: "Access denied",
+		LocaleRU: "Доступ запрещён",
+	},
+	CodeForbiddenNoScopes: {
+		LocaleEN: "Scopes {scopes} are required",
+		LocaleRU: "Требуются права {scopes}",
+	},
+	CodeForbiddenPermission: {
+		LocaleEN: "Permission {permission} is not granted",
+		LocaleRU: "Разрешение {permission} не выдано",
+	},
+	CodeRateLimited: {
+		LocaleEN: "Too many requests, retry in {retry_after} s",
+		LocaleRU: "Слишком много запросов, повторите через {retry_after} с",
+	},
+	CodeOverloaded: {
+		LocaleEN: "Service is overloaded, {group} requests are not admitted",
+		LocaleRU: "Сервис перегружен, запросы {group} не принимаются",
+	},
+	CodeStopping: {
+		LocaleEN: "Service is stopping, retry on another instance",
+		LocaleRU: "Сервис останавливается, повторите запрос на другом экземпляре",
+	},
+	CodeIdempotencyKeyReused: {
+		LocaleEN: "Idempotency key {key} was already used with another request",
+		LocaleRU: "Ключ идемпотентности {key} уже использован с другим запросом",
+	},
+	CodeIdempotencyInProgress: {
+		LocaleEN: "Request with idempotency key {key} is still in progress",
+		LocaleRU: "Запрос с ключом идемпотентности {key} ещё выполняется",
+	},
+}
+
+// Message renders the message of the code in locale, falls back to DefaultLocale and then to the code itself
+func (c ErrorCode) Message(locale Locale, params Params) string {
+	messages, ok := catalog[c]
+	if !ok {
+		return string(c)
+	}
+	msg, ok := messages[locale]
+	if !ok {
+		msg = messages[DefaultLocale]
+	}
+
+	for k, v := range params {
+		msg = strings.ReplaceAll(msg, "{"+k+"}", v)
+	}
+	return msg
+}
+
+type CatalogEntry struct {
+	Code     ErrorCode         `json:"code"`
+	Messages map[Locale]string `json:"messages"`
+}
+
+// Catalog returns every error code with its message templates sorted by code, for client code generation
+func Catalog() []CatalogEntry {
+	entries := make([]CatalogEntry, 0, len(catalog))
+	for code, messages := range catalog {
+		m := make(map[Locale]string, len(messages))
+		for l, msg := range messages {
+			m[l] = msg
+		}
+		entries = append(entries, CatalogEntry{code, m})
+	}
+	slices.SortFunc(entries, func(a, b CatalogEntry) int {
+		return strings.Compare(string(a.Code), string(b.Code))
+	})
+	return entries
+}
+
+// Locales returns locales of the catalog, DefaultLocale first
+func Locales() []Locale {
+	return []Locale{DefaultLocale, LocaleRU}
+}
@@ -1,9 +1,73 @@
 package domain
 
+import (
+	"go-clean-template/pkg/validate"
+	"strings"
+	"time"
+)
+
+// ServiceRequest parameters are optional, callers without them get the full data as before
 type ServiceRequest struct {
+	DateFrom string `query:"date_from" validate:"date=2006-01-02"`
+	DateTo   string `query:"date_to"   validate:"date=2006-01-02"`
+	Limit    *int   `query:"limit"     validate:"min=1,max=1000"`
+	Sort     string `query:"sort"      validate:"oneof=asc|desc"`
 }
 
 func (r *ServiceRequest) Validate() error {
-	// IMPLEMENT ME
+	err := ValidateStruct(r)
+	if err != nil {
+		return err
+	}
+	if r.DateFrom == "" || r.DateTo == "" {
+		return nil
+	}
+
+	// the formats are checked by the tags
+	from, _ := time.Parse(time.DateOnly, r.DateFrom)
+	to, _ := time.Parse(time.DateOnly, r.DateTo)
+	if to.Before(from) {
+		return NewValidationError(NewFieldError("date_to", CodeFieldInvalidRange, Params{"param": "date_from"}))
+	}
 	return nil
 }
+
+var ruleCodes = map[string]ErrorCode{ //nolint:gochecknoglobals //validate rules to catalog codes
+	validate.RuleRequired: CodeFieldRequired,
+	validate.RuleMin:      CodeFieldTooSmall,
+	validate.RuleMax:      CodeFieldTooLarge,
+	validate.RuleOneOf:    CodeFieldNotAllowed,
+	validate.RuleDate:     CodeFieldInvalidDate,
+	validate.RuleRegex:    CodeFieldInvalidFmt,
+	validate.RuleType:     CodeFieldInvalidType,
+}
+
+// ValidateStruct checks the "validate" tags of v and collects every violation into ValidationError
+func ValidateStruct(v any) error {
+	return ValidationErrorOf(validate.Struct(v))
+}
+
+// ValidationErrorOf maps violations of validate rules to catalog codes, it returns nil if there are none
+func ValidationErrorOf(errs []validate.FieldError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	fields := make([]FieldError, 0, len(errs))
+	for _, e := range errs {
+		code, ok := ruleCodes[e.Rule]
+		if !ok {
+			fields = append(fields, FieldError{Field: e.Field, Code: CodeValidationFailed, Rule: e.Rule,
+				Message: e.Error()})
+			continue
+		}
+		param := e.Param
+		if e.Rule == validate.RuleOneOf {
+			param = strings.ReplaceAll(param, "|", ", ")
+		}
+		f := NewFieldError(e.Field, code, Params{"param": param})
+		f.Rule = e.Rule
+		fields = append(fields, f)
+	}
+	return NewValidationError(fields...)
+}
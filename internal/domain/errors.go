@@ -1,29 +1,179 @@
 package domain
 
-var ErrNotFound = NotFoundError{Message: "not found"}
-var ErrAlreadyProcessed = AlreadyProcessedError{Message: "already processed"}
-var ErrValidationError = ValidationError{Message: "validation error"}
+import "strings"
+
+var ErrNotFound = NotFoundError{Message: "not found", Code: CodeDataNotFound}
+var ErrAlreadyProcessed = AlreadyProcessedError{Message: "already processed", Code: CodeAlreadyProcessed}
+var ErrValidationError = ValidationError{Message: "validation error", Code: CodeValidationFailed}
+
+// CodedError is implemented by domain errors that carry a catalog code
+type CodedError interface {
+	error
+	ErrorCode() ErrorCode
+	ErrorParams() Params
+}
 
 type NotFoundError struct {
 	Message string
+	Code    ErrorCode
+	Params  Params
+}
+
+func NewNotFoundError(code ErrorCode, params Params) NotFoundError {
+	return NotFoundError{code.Message(DefaultLocale, params), code, params}
 }
 
 func (e NotFoundError) Error() string {
 	return e.Message
 }
 
+func (e NotFoundError) ErrorCode() ErrorCode {
+	return codeOrDefault(e.Code, CodeDataNotFound)
+}
+
+func (e NotFoundError) ErrorParams() Params {
+	return e.Params
+}
+
 type AlreadyProcessedError struct {
 	Message string
+	Code    ErrorCode
+	Params  Params
+}
+
+func NewAlreadyProcessedError(code ErrorCode, params Params) AlreadyProcessedError {
+	return AlreadyProcessedError{code.Message(DefaultLocale, params), code, params}
 }
 
 func (e AlreadyProcessedError) Error() string {
 	return e.Message
 }
 
+func (e AlreadyProcessedError) ErrorCode() ErrorCode {
+	return codeOrDefault(e.Code, CodeAlreadyProcessed)
+}
+
+func (e AlreadyProcessedError) ErrorParams() Params {
+	return e.Params
+}
+
+// UnavailableError is returned while the service is stopping or can't take the request for a while
+type UnavailableError struct {
+	Message string
+	Code    ErrorCode
+	Params  Params
+}
+
+func NewUnavailableError(code ErrorCode, params Params) UnavailableError {
+	return UnavailableError{code.Message(DefaultLocale, params), code, params}
+}
+
+func (e UnavailableError) Error() string {
+	return e.Message
+}
+
+func (e UnavailableError) ErrorCode() ErrorCode {
+	return codeOrDefault(e.Code, CodeStopping)
+}
+
+func (e UnavailableError) ErrorParams() Params {
+	return e.Params
+}
+
+type UnauthorizedError struct {
+	Message string
+	Code    ErrorCode
+	Params  Params
+}
+
+func NewUnauthorizedError(code ErrorCode, params Params) UnauthorizedError {
+	return UnauthorizedError{code.Message(DefaultLocale, params), code, params}
+}
+
+func (e UnauthorizedError) Error() string {
+	return e.Message
+}
+
+func (e UnauthorizedError) ErrorCode() ErrorCode {
+	return codeOrDefault(e.Code, CodeAuthRequired)
+}
+
+func (e UnauthorizedError) ErrorParams() Params {
+	return e.Params
+}
+
+type ForbiddenError struct {
+	Message string
+	Code    ErrorCode
+	Params  Params
+}
+
+func NewForbiddenError(code ErrorCode, params Params) ForbiddenError {
+	return ForbiddenError{code.Message(DefaultLocale, params), code, params}
+}
+
+func (e ForbiddenError) Error() string {
+	return e.Message
+}
+
+func (e ForbiddenError) ErrorCode() ErrorCode {
+	return codeOrDefault(e.Code, CodeForbidden)
+}
+
+func (e ForbiddenError) ErrorParams() Params {
+	return e.Params
+}
+
 type ValidationError struct {
 	Message string
+	Code    ErrorCode
+	Params  Params
+	Fields  []FieldError
+}
+
+type FieldError struct {
+	Field   string    `json:"field"`
+	Code    ErrorCode `json:"code"`
+	Rule    string    `json:"rule,omitempty"`
+	Params  Params    `json:"-"`
+	Message string    `json:"message"`
+}
+
+func NewValidationError(fields ...FieldError) ValidationError {
+	return ValidationError{ErrValidationError.Message, CodeValidationFailed, nil, fields}
+}
+
+// NewFieldError adds field name to params as {field}
+func NewFieldError(field string, code ErrorCode, params Params) FieldError {
+	p := Params{"field": field}
+	for k, v := range params {
+		p[k] = v
+	}
+	return FieldError{field, code, "", p, code.Message(DefaultLocale, p)}
 }
 
 func (e ValidationError) Error() string {
-	return e.Message
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+	fields := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		fields = append(fields, f.Message)
+	}
+	return e.Message + ": " + strings.Join(fields, "; ")
+}
+
+func (e ValidationError) ErrorCode() ErrorCode {
+	return codeOrDefault(e.Code, CodeValidationFailed)
+}
+
+func (e ValidationError) ErrorParams() Params {
+	return e.Params
+}
+
+func codeOrDefault(code, def ErrorCode) ErrorCode {
+	if code == "" {
+		return def
+	}
+	return code
 }
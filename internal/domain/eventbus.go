@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"go-clean-template/pkg/logger"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const eventsMetrics = "events"
+
+// EventMetrics is the part of monitoring the bus reports to
+type EventMetrics interface {
+	Register(packageName string)
+	Observe(packageName string, method string, value float64)
+	Count(packageName string, method string, fail bool)
+}
+
+type subscription struct {
+	subscriber string
+	mode       DeliveryMode
+	h          EventHandler
+}
+
+type eventBus struct {
+	mu     sync.RWMutex
+	subs   map[string][]subscription
+	outbox Outbox
+	wg     sync.WaitGroup
+	mon    EventMetrics
+	lg     logger.Logger
+}
+
+// NewEventBus creates in-process bus, outbox may be nil if durable delivery is not needed
+func NewEventBus(outbox Outbox, mon EventMetrics, lg logger.Logger) *eventBus {
+	mon.Register(eventsMetrics)
+	return &eventBus{
+		subs:   make(map[string][]subscription),
+		outbox: outbox,
+		mon:    mon,
+		lg:     lg,
+	}
+}
+
+func (b *eventBus) Subscribe(name, subscriber string, mode DeliveryMode, h EventHandler) {
+	b.mu.Lock()
+	b.subs[name] = append(b.subs[name], subscription{subscriber, mode, h})
+	b.mu.Unlock()
+}
+
+// Publish writes OutboxEvent to the outbox in the transaction of ctx, then delivers e to subscribers once
+// it is committed. Only the outbox error is returned, errors and panics of handlers are logged,
+// a subscriber does not fail the producer.
+func (b *eventBus) Publish(ctx context.Context, e Event) error {
+	const op = "eventBus.Publish"
+	name := e.EventName()
+
+	if oe, ok := e.(OutboxEvent); ok && b.outbox != nil {
+		if err := b.outbox.Add(ctx, oe); err != nil {
+			b.mon.Count(eventsMetrics, name+" outbox", true)
+			return fmt.Errorf("%s: outbox: %w", op, err)
+		}
+	}
+	b.mon.Count(eventsMetrics, name, false)
+
+	AfterCommit(ctx, func(ctx context.Context) {
+		b.dispatch(ctx, e)
+	})
+	return nil
+}
+
+func (b *eventBus) dispatch(ctx context.Context, e Event) {
+	const op = "eventBus.dispatch"
+
+	b.mu.RLock()
+	subs := b.subs[e.EventName()]
+	b.mu.RUnlock()
+
+	for _, s := range subs {
+		if s.mode == DeliveryAsync {
+			b.wg.Add(1)
+			go func() {
+				defer b.wg.Done()
+				if err := b.deliver(context.WithoutCancel(ctx), s, e); err != nil {
+					b.lg.WithContext(ctx).Error(fmt.Errorf("%s: %w", op, err))
+				}
+			}()
+			continue
+		}
+		if err := b.deliver(ctx, s, e); err != nil {
+			b.lg.WithContext(ctx).Error(fmt.Errorf("%s: %w", op, err))
+		}
+	}
+}
+
+func (b *eventBus) deliver(ctx context.Context, s subscription, e Event) (err error) {
+	method := e.EventName() + " " + s.subscriber
+	tn := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v, stacktrace: %s", r, debug.Stack())
+		}
+		if err != nil {
+			err = fmt.Errorf("%s: %w", method, err)
+		}
+		b.mon.Observe(eventsMetrics, method, time.Since(tn).Seconds())
+		b.mon.Count(eventsMetrics, method, err != nil)
+	}()
+	return s.h(ctx, e)
+}
+
+// Close waits for async handlers
+func (b *eventBus) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("eventBus.Close: %w", ctx.Err())
+	}
+}
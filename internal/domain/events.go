@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventPersistCompleted = "persist.completed"
+	EventJobRunStarted    = "job.run.started"
+	EventJobRunFinished   = "job.run.finished"
+)
+
+// Event is a fact that happened in the domain, handlers are subscribed by EventName
+type Event interface {
+	EventName() string
+}
+
+// OutboxEvent is additionally written to the outbox on publishing, so it is delivered
+// outside the process even if the process dies. OutboxKey orders events of the same entity.
+type OutboxEvent interface {
+	Event
+	OutboxKey() string
+}
+
+type DeliveryMode int
+
+const (
+	// DeliverySync runs the handler in the publisher goroutine, its error is logged
+	DeliverySync DeliveryMode = iota
+	// DeliveryAsync runs the handler in its own goroutine, its error is only logged
+	DeliveryAsync
+)
+
+type EventHandler func(ctx context.Context, e Event) error
+
+type EventBus interface {
+	Publish(ctx context.Context, e Event) error
+	Subscribe(name, subscriber string, mode DeliveryMode, h EventHandler)
+	Close(ctx context.Context) error
+}
+
+// ErrEventTooLarge is returned by EventRelay for events it can never deliver
+var ErrEventTooLarge = errors.New("event is too large to relay")
+
+// EventRelay shares events with every instance of the service, this one included
+type EventRelay interface {
+	Relay(ctx context.Context, e Event) error
+	// Listen calls h with the name and the JSON of every relayed event
+	Listen(h func(name string, payload []byte))
+}
+
+type Outbox interface {
+	Add(ctx context.Context, e OutboxEvent) error
+}
+
+// OutboxMessage is a stored OutboxEvent, Payload is the JSON of the event
+type OutboxMessage struct {
+	ID      uuid.UUID
+	Name    string
+	Payload json.RawMessage
+}
+
+func (m OutboxMessage) EventName() string {
+	return m.Name
+}
+
+// MarshalJSON returns the stored JSON, so a relayed message can't be told from the event
+func (m OutboxMessage) MarshalJSON() ([]byte, error) {
+	return m.Payload, nil
+}
+
+// Subscribe subscribes a typed handler to events of type E
+func Subscribe[E Event](bus EventBus, subscriber string, mode DeliveryMode, h func(ctx context.Context, e E) error) {
+	var zero E
+	bus.Subscribe(zero.EventName(), subscriber, mode, func(ctx context.Context, e Event) error {
+		te, ok := e.(E)
+		if !ok {
+			return nil
+		}
+		return h(ctx, te)
+	})
+}
+
+type PersistCompleted struct {
+	Date       string    `json:"date"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+func (PersistCompleted) EventName() string {
+	return EventPersistCompleted
+}
+
+func (e PersistCompleted) OutboxKey() string {
+	return e.Date
+}
+
+type JobRunStarted struct {
+	JobRun
+}
+
+func (JobRunStarted) EventName() string {
+	return EventJobRunStarted
+}
+
+// JobRunFinished is published when a run succeeded or failed
+type JobRunFinished struct {
+	JobRun
+}
+
+func (JobRunFinished) EventName() string {
+	return EventJobRunFinished
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"context"
+	"go-clean-template/pkg/listquery"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	JobPersist          = "persist"
+	JobBackfill         = "backfill"
+	JobIdempotencyPurge = "idempotency-purge"
+	JobRateLimitPurge   = "rate-limit-purge"
+	JobOutboxPurge      = "outbox-purge"
+)
+
+type JobStatus string
+
+const (
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+type JobTrigger string
+
+const (
+	JobTriggerSchedule JobTrigger = "schedule"
+	JobTriggerManual   JobTrigger = "manual"
+)
+
+type JobFunc func(ctx context.Context, params map[string]string) error
+
+type JobRun struct {
+	ID         uuid.UUID         `json:"id"`
+	JobName    string            `json:"job_name"`
+	InstanceID uuid.UUID         `json:"instance_id"`
+	Trigger    JobTrigger        `json:"trigger"`
+	Status     JobStatus         `json:"status"`
+	Params     map[string]string `json:"params"`
+	Error      string            `json:"error,omitempty"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+}
+
+//nolint:gochecknoglobals //fields of the list of runs
+var JobRunList = listquery.Spec{
+	Fields: map[string]listquery.Field{
+		"id":          {Column: "id", Type: listquery.UUID, Filter: true, Sort: true},
+		"instance_id": {Column: "instance_id", Type: listquery.UUID, Filter: true},
+		"trigger": {Column: "triggered_by", Filter: true,
+			Values: []string{string(JobTriggerSchedule), string(JobTriggerManual)}},
+		"status": {Column: "status", Filter: true,
+			Values: []string{string(JobStatusRunning), string(JobStatusSucceeded), string(JobStatusFailed)}},
+		"started_at": {Column: "started_at", Type: listquery.Time, Filter: true, Sort: true},
+	},
+	Key:          "id",
+	Sort:         "-started_at",
+	DefaultLimit: 20,
+	MaxLimit:     1000,
+}
+
+// ListValue returns the value of a field of JobRunList
+func (r JobRun) ListValue(field string) any {
+	switch field {
+	case "id":
+		return r.ID
+	case "started_at":
+		return r.StartedAt
+	}
+	return nil
+}
+
+type Job struct {
+	Name    string  `json:"name"`
+	LastRun *JobRun `json:"last_run,omitempty"`
+}
+
+type JobService interface {
+	// Run executes a registered job and blocks until it finishes
+	Run(ctx context.Context, name string, trigger JobTrigger, params map[string]string) (JobRun, error)
+	// Trigger starts a registered job in background and returns the started run
+	Trigger(ctx context.Context, name string, params map[string]string) (JobRun, error)
+	// Track records an execution of fn as a run of the job with the given name
+	Track(ctx context.Context, name string, trigger JobTrigger, params map[string]string,
+		fn func(ctx context.Context) error) (JobRun, error)
+	Jobs(ctx context.Context) ([]Job, error)
+	// Runs returns a page of runs of the job with one more run if there is a next page
+	Runs(ctx context.Context, name string, q listquery.Query) ([]JobRun, error)
+	// Close refuses new manual runs and waits for the running ones
+	Close(ctx context.Context) error
+}
@@ -0,0 +1,198 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+const (
+	PermissionJobsTrigger = "jobs:trigger"
+
+	// PolicyAnySubject in a binding matches every authenticated principal
+	PolicyAnySubject = "*"
+)
+
+// ParseSubject splits a method prefixed subject like jwt:alice, a subject of another auth method
+// must not get the roles of a namesake
+func ParseSubject(subject string) (Principal, bool) {
+	method, sub, ok := strings.Cut(subject, ":")
+	if !ok || sub == "" || !slices.Contains([]string{AuthMethodJWT, AuthMethodAPIKey, AuthMethodClientCert}, method) {
+		return Principal{}, false
+	}
+	return Principal{Subject: sub, Method: method}, true
+}
+
+// Role grants permissions. A permission is a colon separated path like "jobs:trigger:persist",
+// a "*" segment matches any segment and a granted permission covers every permission it is a prefix of:
+// "jobs" and "jobs:*" grant "jobs:trigger:persist".
+type Role struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleBinding gives roles to a principal, Subject is PolicyAnySubject or matched against Principal.Actor()
+type RoleBinding struct {
+	Subject string   `json:"subject"`
+	Roles   []string `json:"roles"`
+}
+
+// RoutePermission binds a route template to a permission, it overrides the permission of the route registration
+type RoutePermission struct {
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Permission string `json:"permission"`
+}
+
+type Policy struct {
+	Roles    []Role            `json:"roles"`
+	Bindings []RoleBinding     `json:"bindings"`
+	Routes   []RoutePermission `json:"routes"`
+}
+
+type Decision struct {
+	Allowed    bool     `json:"allowed"`
+	Subject    string   `json:"subject"`
+	Permission string   `json:"permission"`
+	Roles      []string `json:"roles"`
+	Role       string   `json:"matched_role,omitempty"`
+	Grant      string   `json:"matched_grant,omitempty"`
+	Reason     string   `json:"reason"`
+}
+
+// ExplainRequest subject is method prefixed like jwt:alice, the permission is taken from the route binding
+// if it is not set
+type ExplainRequest struct {
+	Subject    string `query:"subject"    validate:"required"`
+	Permission string `query:"permission"`
+	Resource   string `query:"resource"`
+	Method     string `query:"method"`
+	Path       string `query:"path"`
+}
+
+func (r *ExplainRequest) Validate() error {
+	err := ValidateStruct(r)
+	if err != nil {
+		return err
+	}
+	if _, ok := ParseSubject(r.Subject); !ok {
+		return NewValidationError(NewFieldError("subject", CodeFieldInvalidFmt, Params{"param": "method:subject"}))
+	}
+	if r.Permission == "" && (r.Method == "" || r.Path == "") {
+		return NewValidationError(NewFieldError("permission", CodeFieldRequired, nil))
+	}
+	return nil
+}
+
+// Principal returns the principal of the explained subject
+func (r *ExplainRequest) Principal() Principal {
+	p, _ := ParseSubject(r.Subject)
+	return p
+}
+
+type PolicyService interface {
+	// Enabled is false if every decision is allowed
+	Enabled() bool
+	// Policy returns the merged policy of config and database
+	Policy() Policy
+	// Decide checks the permission on the resource for the principal, resource may be empty.
+	// Denials are logged with the request ID of ctx.
+	Decide(ctx context.Context, p Principal, permission, resource string) Decision
+	// RoutePermission returns the permission bound to the route template by the policy
+	RoutePermission(method, path string) (string, bool)
+	// Explain decides for the subject like Decide and tells which rule made the decision
+	Explain(ctx context.Context, req ExplainRequest) (Decision, error)
+}
+
+// Authorize checks a resource-level permission of the principal in ctx.
+// Calls without a principal are not made on behalf of a caller (jobs, CLI) and are allowed,
+// routes reject unauthenticated requests before services are called.
+func Authorize(ctx context.Context, ps PolicyService, permission, resource string) error {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok || !ps.Enabled() {
+		return nil
+	}
+	d := ps.Decide(ctx, p, permission, resource)
+	if !d.Allowed {
+		return NewForbiddenError(CodeForbiddenPermission, Params{"permission": d.Permission})
+	}
+	return nil
+}
+
+// Decide is the policy evaluation, the first role granting the permission wins
+func (pol Policy) Decide(p Principal, permission, resource string) Decision {
+	required := permission
+	if resource != "" {
+		required += ":" + resource
+	}
+	subject := p.Subject
+	if p.Method != "" {
+		subject = p.Actor()
+	}
+	d := Decision{
+		Subject:    subject,
+		Permission: required,
+		Roles:      pol.RolesOf(p),
+	}
+
+	if len(d.Roles) == 0 {
+		d.Reason = "no role is bound to the subject"
+		return d
+	}
+	for _, name := range d.Roles {
+		i := slices.IndexFunc(pol.Roles, func(r Role) bool { return r.Name == name })
+		if i < 0 {
+			continue
+		}
+		for _, grant := range pol.Roles[i].Permissions {
+			if grants(grant, required) {
+				d.Allowed, d.Role, d.Grant = true, name, grant
+				d.Reason = "role " + name + " grants " + grant
+				return d
+			}
+		}
+	}
+	d.Reason = "no role of the subject grants " + required
+	return d
+}
+
+// RoutePermission returns the permission bound to the route
+func (pol Policy) RoutePermission(method, path string) (string, bool) {
+	for _, r := range pol.Routes {
+		if strings.EqualFold(r.Method, method) && r.Path == path {
+			return r.Permission, true
+		}
+	}
+	return "", false
+}
+
+// RolesOf returns the roles bound to the principal, a principal without auth method only gets the roles
+// bound to PolicyAnySubject
+func (pol Policy) RolesOf(p Principal) []string {
+	roles := make([]string, 0)
+	for _, b := range pol.Bindings {
+		if b.Subject != PolicyAnySubject && (p.Method == "" || b.Subject != p.Actor()) {
+			continue
+		}
+		for _, r := range b.Roles {
+			if !slices.Contains(roles, r) {
+				roles = append(roles, r)
+			}
+		}
+	}
+	return roles
+}
+
+func grants(granted, required string) bool {
+	g := strings.Split(granted, ":")
+	r := strings.Split(required, ":")
+	if len(g) > len(r) {
+		return false
+	}
+	for i := range g {
+		if g[i] != "*" && g[i] != r[i] {
+			return false
+		}
+	}
+	return true
+}
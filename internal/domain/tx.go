@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"sync"
+)
+
+// Transactor runs fn in a transaction, repositories called with ctx of fn take part in it.
+// A ctx that is already in a transaction joins it.
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txHooksKey struct{}
+
+// TxHooks collect the functions of AfterCommit while a transaction is open
+type TxHooks struct {
+	mu  sync.Mutex
+	fns []func(ctx context.Context)
+}
+
+func ContextWithTxHooks(ctx context.Context, h *TxHooks) context.Context {
+	return context.WithValue(ctx, txHooksKey{}, h)
+}
+
+// Run calls the collected functions with ctx, that must be out of the committed transaction
+func (h *TxHooks) Run(ctx context.Context) {
+	h.mu.Lock()
+	fns := h.fns
+	h.fns = nil
+	h.mu.Unlock()
+	for _, fn := range fns {
+		fn(ctx)
+	}
+}
+
+// AfterCommit runs fn once the transaction of ctx is committed, it is dropped on rollback.
+// Without a transaction fn runs at once.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	h, ok := ctx.Value(txHooksKey{}).(*TxHooks)
+	if !ok {
+		fn(ctx)
+		return
+	}
+	h.mu.Lock()
+	h.fns = append(h.fns, fn)
+	h.mu.Unlock()
+}
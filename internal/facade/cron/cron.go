@@ -13,7 +13,7 @@ import (
 
 type cron struct {
 	scheds        config.Schedules
-	service       domain.Service
+	jobs          domain.JobService
 	cs            Crons
 	baseCtx       context.Context
 	cancelBaseCtx context.CancelFunc
@@ -22,6 +22,7 @@ type cron struct {
 
 type Provider interface {
 	GetService() domain.Service
+	GetJobService() domain.JobService
 	GetAppVersion() string
 	GetMonitoring() monitoring.Monitoring
 	GetLogger() logger.Logger
@@ -36,7 +37,7 @@ type Crons interface {
 func New(cfg config.Schedules, prov Provider) *cron {
 	return &cron{
 		cfg,
-		prov.GetService(),
+		prov.GetJobService(),
 		crons.New(prov.GetLogger()),
 		nil,
 		nil,
@@ -51,7 +52,7 @@ func (c *cron) persistYesterdayData() {
 
 	yesterday := tn.AddDate(0, 0, -1).Format(time.DateOnly)
 
-	err := c.service.Persist(c.baseCtx, yesterday)
+	_, err := c.jobs.Run(c.baseCtx, domain.JobPersist, domain.JobTriggerSchedule, map[string]string{"dt": yesterday})
 	if err != nil {
 		c.lg.Error(fmt.Errorf("%s: %w", op, err))
 	}
@@ -59,6 +60,33 @@ func (c *cron) persistYesterdayData() {
 	c.lg.Info(fmt.Sprintf("%s done in %v", op, time.Since(tn)))
 }
 
+func (c *cron) purgeIdempotencyKeys() {
+	const op = "cron.purgeIdempotencyKeys"
+
+	_, err := c.jobs.Run(c.baseCtx, domain.JobIdempotencyPurge, domain.JobTriggerSchedule, nil)
+	if err != nil {
+		c.lg.Error(fmt.Errorf("%s: %w", op, err))
+	}
+}
+
+func (c *cron) purgeRateLimits() {
+	const op = "cron.purgeRateLimits"
+
+	_, err := c.jobs.Run(c.baseCtx, domain.JobRateLimitPurge, domain.JobTriggerSchedule, nil)
+	if err != nil {
+		c.lg.Error(fmt.Errorf("%s: %w", op, err))
+	}
+}
+
+func (c *cron) purgeOutbox() {
+	const op = "cron.purgeOutbox"
+
+	_, err := c.jobs.Run(c.baseCtx, domain.JobOutboxPurge, domain.JobTriggerSchedule, nil)
+	if err != nil {
+		c.lg.Error(fmt.Errorf("%s: %w", op, err))
+	}
+}
+
 func (c *cron) Run(ctx context.Context) error {
 	c.baseCtx, c.cancelBaseCtx = context.WithCancel(ctx)
 
@@ -66,6 +94,26 @@ func (c *cron) Run(ctx context.Context) error {
 	if err != nil {
 		c.lg.Error("failed to add cron", err)
 	}
+	if c.scheds.IdempotencyPurge != "" {
+		err = c.cs.AddCron(c.scheds.IdempotencyPurge, c.purgeIdempotencyKeys)
+		if err != nil {
+			c.lg.Error("failed to add cron", err)
+		}
+	}
+
+	if c.scheds.RateLimitPurge != "" {
+		err = c.cs.AddCron(c.scheds.RateLimitPurge, c.purgeRateLimits)
+		if err != nil {
+			c.lg.Error("failed to add cron", err)
+		}
+	}
+
+	if c.scheds.OutboxPurge != "" {
+		err = c.cs.AddCron(c.scheds.OutboxPurge, c.purgeOutbox)
+		if err != nil {
+			c.lg.Error("failed to add cron", err)
+		}
+	}
 
 	c.cs.Start()
 	return nil
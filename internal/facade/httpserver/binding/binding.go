@@ -0,0 +1,258 @@
+// Package binding fills request DTOs from the parts of http.Request named by struct tags:
+//
+//	ID     uuid.UUID `path:"id"`
+//	Limit  int       `query:"limit"`
+//	Tenant string    `header:"X-Tenant"`
+//	Name   string    `json:"name"`
+//
+// Slices are filled from repeated or comma separated values, types implementing
+// encoding.TextUnmarshaler decode themselves. After decoding Bind validates the DTO.
+package binding
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-clean-template/internal/domain"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	TagPath   = "path"
+	TagQuery  = "query"
+	TagHeader = "header"
+)
+
+type validator interface {
+	Validate() error
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem() //nolint:gochecknoglobals //.
+
+// Bind decodes r into dst and validates it with dst.Validate() or with the "validate" tags.
+// Malformed input is reported as domain.ValidationError with an entry per field.
+func Bind(r *http.Request, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("binding.Bind: dst must be a pointer to struct, got %T", dst)
+	}
+
+	fields := make([]domain.FieldError, 0)
+
+	fe, err := decodeBody(r, dst)
+	if err != nil {
+		return fmt.Errorf("binding.Bind: %w", err)
+	}
+	if fe != nil {
+		fields = append(fields, *fe)
+	}
+	fields = append(fields, bindParams(r, rv.Elem())...)
+
+	if len(fields) > 0 {
+		return domain.NewValidationError(fields...)
+	}
+
+	if v, ok := dst.(validator); ok {
+		return v.Validate()
+	}
+	return domain.ValidateStruct(dst)
+}
+
+// BindParams is Bind without the body, it leaves the body to be read by the handler
+func BindParams(r *http.Request, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("binding.BindParams: dst must be a pointer to struct, got %T", dst)
+	}
+
+	fields := bindParams(r, rv.Elem())
+	if len(fields) > 0 {
+		return domain.NewValidationError(fields...)
+	}
+
+	if v, ok := dst.(validator); ok {
+		return v.Validate()
+	}
+	return domain.ValidateStruct(dst)
+}
+
+// decodeBody returns an error if the body exceeds the limits of the route, malformed JSON is a field error.
+// Only fields without a path, query or header tag are taken from the body, so each value has one source.
+func decodeBody(r *http.Request, dst any) (*domain.FieldError, error) {
+	if r.Body == nil || r.ContentLength == 0 {
+		return nil, nil
+	}
+
+	rv := reflect.ValueOf(dst).Elem()
+	body := reflect.New(rv.Type())
+	body.Elem().Set(rv)
+	err := json.NewDecoder(r.Body).Decode(body.Interface())
+	if err == nil || errors.Is(err, io.EOF) {
+		copyBodyFields(rv, body.Elem())
+		return nil, nil
+	}
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return nil, err
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		fe := domain.NewFieldError(typeErr.Field, domain.CodeFieldInvalidType,
+			domain.Params{"param": typeErr.Type.String()})
+		return &fe, nil
+	}
+	fe := domain.NewFieldError("body", domain.CodeFieldInvalidType, domain.Params{"param": "JSON"})
+	return &fe, nil
+}
+
+func copyBodyFields(dst, src reflect.Value) {
+	rt := dst.Type()
+	for i := range rt.NumField() {
+		f := rt.Field(i)
+		switch {
+		case !f.IsExported():
+		case f.Anonymous && f.Type.Kind() == reflect.Struct:
+			copyBodyFields(dst.Field(i), src.Field(i))
+		case f.Tag.Get(TagPath) == "" && f.Tag.Get(TagQuery) == "" && f.Tag.Get(TagHeader) == "":
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
+
+func bindParams(r *http.Request, rv reflect.Value) []domain.FieldError {
+	var fields []domain.FieldError
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+
+		if f.Anonymous && fv.Kind() == reflect.Struct {
+			fields = append(fields, bindParams(r, fv)...)
+			continue
+		}
+
+		var name string
+		var raw []string
+		switch {
+		case f.Tag.Get(TagPath) != "":
+			name = f.Tag.Get(TagPath)
+			if v, ok := vars[name]; ok {
+				raw = []string{v}
+			}
+		case f.Tag.Get(TagQuery) != "":
+			name = f.Tag.Get(TagQuery)
+			raw = query[name]
+		case f.Tag.Get(TagHeader) != "":
+			name = f.Tag.Get(TagHeader)
+			raw = r.Header.Values(name)
+		default:
+			continue
+		}
+
+		if len(raw) == 0 {
+			continue
+		}
+		if err := setValue(fv, raw); err != nil {
+			fields = append(fields,
+				domain.NewFieldError(name, domain.CodeFieldInvalidType, domain.Params{"param": err.Error()}))
+		}
+	}
+	return fields
+}
+
+// setValue returns the name of the expected type as error if raw can't be converted
+func setValue(fv reflect.Value, raw []string) error {
+	if fv.Kind() == reflect.Pointer {
+		v := reflect.New(fv.Type().Elem())
+		if err := setValue(v.Elem(), raw); err != nil {
+			return err
+		}
+		fv.Set(v)
+		return nil
+	}
+
+	if reflect.PointerTo(fv.Type()).Implements(textUnmarshalerType) {
+		u := fv.Addr().Interface().(encoding.TextUnmarshaler) //nolint:forcetypeassert //checked above
+		if err := u.UnmarshalText([]byte(raw[0])); err != nil {
+			return errors.New(fv.Type().Name())
+		}
+		return nil
+	}
+
+	if fv.Kind() == reflect.Slice {
+		items := make([]string, 0, len(raw))
+		for _, r := range raw {
+			items = append(items, strings.Split(r, ",")...)
+		}
+		slice := reflect.MakeSlice(fv.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), []string{strings.TrimSpace(item)}); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+
+	return setScalar(fv, raw[0])
+}
+
+func setScalar(fv reflect.Value, s string) error {
+	if fv.Type() == reflect.TypeOf(time.Time{}) {
+		for _, layout := range []string{time.RFC3339, time.DateOnly} {
+			if t, err := time.Parse(layout, s); err == nil {
+				fv.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return errors.New("date or RFC 3339 timestamp")
+	}
+
+	switch fv.Kind() { //nolint:exhaustive //other kinds can't be bound from strings
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("boolean")
+		}
+		fv.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return errors.New("integer")
+		}
+		fv.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return errors.New("non-negative integer")
+		}
+		fv.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return errors.New("number")
+		}
+		fv.SetFloat(v)
+	default:
+		return errors.New(fv.Type().String())
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"go-clean-template/config"
+	"go-clean-template/internal/facade/httpserver/problem"
+	"go-clean-template/pkg/logger"
+	"net/http"
+)
+
+type ConfigProvider interface {
+	GetConfig() *config.Config
+	ReloadConfig(ctx context.Context) (*config.Config, error)
+}
+
+type adminHandler struct {
+	cfg  ConfigProvider
+	errs ErrorRenderer
+	lg   logger.Logger
+}
+
+func NewAdminHandler(prov Provider) *adminHandler {
+	return &adminHandler{
+		prov,
+		problem.New(prov),
+		prov.GetLogger(),
+	}
+}
+
+// GetConfig returns the running config, secrets are not marshaled
+func (h *adminHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, err := w.Write([]byte(h.cfg.GetConfig().ConfigString))
+	if err != nil {
+		h.lg.WithContext(r.Context()).Error("Error writing response", err)
+	}
+}
+
+// ConfigState returns the running config, it is the audited state of reloads
+func (h *adminHandler) ConfigState(_ *http.Request) (any, error) {
+	return json.RawMessage(h.cfg.GetConfig().ConfigString), nil
+}
+
+func (h *adminHandler) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	cfg, err := h.cfg.ReloadConfig(r.Context())
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write([]byte(cfg.ConfigString))
+	if err != nil {
+		h.lg.WithContext(r.Context()).Error("Error writing response", err)
+	}
+}
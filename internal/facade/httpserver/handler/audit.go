@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"go-clean-template/internal/domain"
+	"go-clean-template/internal/facade/httpserver/problem"
+	"go-clean-template/pkg/listquery"
+	"go-clean-template/pkg/logger"
+	"net/http"
+)
+
+type auditHandler struct {
+	service domain.AuditService
+	cursors *listquery.Signer
+	errs    ErrorRenderer
+	lg      logger.Logger
+}
+
+func NewAuditHandler(prov Provider) *auditHandler {
+	return &auditHandler{
+		prov.GetAuditService(),
+		listquery.NewSigner(prov.GetConfig().HTTP.Lists.CursorSecret),
+		problem.New(prov),
+		prov.GetLogger(),
+	}
+}
+
+func (h *auditHandler) List(w http.ResponseWriter, r *http.Request) {
+	q, errs := listquery.Parse(r.URL.Query(), &domain.AuditList, h.cursors)
+	if len(errs) > 0 {
+		h.errs.Render(w, r, domain.ValidationErrorOf(errs))
+		return
+	}
+
+	entries, err := h.service.List(r.Context(), q)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+
+	writeList(w, r, h.lg, q, entries, domain.AuditEntry.ListValue)
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"go-clean-template/internal/domain"
+	"go-clean-template/internal/facade/httpserver/binding"
+	"go-clean-template/internal/facade/httpserver/problem"
+	"go-clean-template/pkg/httpcache"
+	"go-clean-template/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+const defaultBackfillListLimit = 50
+
+type backfillHandler struct {
+	service domain.BackfillService
+	errs    ErrorRenderer
+	lg      logger.Logger
+}
+
+func NewBackfillHandler(prov Provider) *backfillHandler {
+	return &backfillHandler{
+		prov.GetBackfillService(),
+		problem.New(prov),
+		prov.GetLogger(),
+	}
+}
+
+type BackfillRequest struct {
+	From string `json:"from" validate:"required,date=2006-01-02"`
+	To   string `json:"to"   validate:"required,date=2006-01-02"`
+}
+
+type BackfillRunRequest struct {
+	ID uuid.UUID `path:"id" validate:"required"`
+}
+
+type BackfillListRequest struct {
+	Limit int `query:"limit" validate:"min=1,max=1000"`
+}
+
+type BackfillResponse struct {
+	Run  domain.BackfillRun   `json:"run"`
+	Days []domain.BackfillDay `json:"days,omitempty"`
+}
+
+func (h *backfillHandler) Start(w http.ResponseWriter, r *http.Request) {
+	req := BackfillRequest{}
+	err := binding.Bind(r, &req)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+
+	run, err := h.service.Create(r.Context(), req.From, req.To)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+
+	err = h.service.Start(r.Context(), run.ID)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+	writeJSON(w, r, h.lg, http.StatusAccepted, BackfillResponse{Run: run})
+}
+
+func (h *backfillHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	req := BackfillRunRequest{}
+	err := binding.Bind(r, &req)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+
+	run, _, err := h.service.Get(r.Context(), req.ID)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+
+	err = h.service.Start(r.Context(), run.ID)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+	writeJSON(w, r, h.lg, http.StatusAccepted, BackfillResponse{Run: run})
+}
+
+func (h *backfillHandler) Get(w http.ResponseWriter, r *http.Request) {
+	req := BackfillRunRequest{}
+	err := binding.Bind(r, &req)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+
+	run, days, err := h.service.Get(r.Context(), req.ID)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+	// changes of the days touch the run, so its update time versions the response
+	etag := httpcache.WeakETag(run.ID.String() + "-" + strconv.FormatInt(run.UpdatedAt.UnixNano(), 10))
+	if httpcache.NotModified(w, r, etag, run.UpdatedAt) {
+		return
+	}
+
+	writeJSON(w, r, h.lg, http.StatusOK, BackfillResponse{Run: run, Days: days})
+}
+
+func (h *backfillHandler) List(w http.ResponseWriter, r *http.Request) {
+	req := BackfillListRequest{Limit: defaultBackfillListLimit}
+	err := binding.Bind(r, &req)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+
+	runs, err := h.service.List(r.Context(), req.Limit)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, h.lg, http.StatusOK, runs)
+}
+
+// State returns the run of the request with its days, it is the audited state of changes to the run
+func (h *backfillHandler) State(r *http.Request) (any, error) {
+	req := BackfillRunRequest{}
+	err := binding.BindParams(r, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	run, days, err := h.service.Get(r.Context(), req.ID)
+	if err != nil {
+		return nil, err
+	}
+	return BackfillResponse{Run: run, Days: days}, nil
+}
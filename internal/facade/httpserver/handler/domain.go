@@ -1,8 +1,9 @@
 package handler
 
 import (
-	"errors"
 	"go-clean-template/internal/domain"
+	"go-clean-template/internal/facade/httpserver/binding"
+	"go-clean-template/internal/facade/httpserver/problem"
 	"go-clean-template/pkg/logger"
 	"go-clean-template/pkg/monitoring"
 
@@ -13,6 +14,7 @@ import (
 
 type domainHandler struct {
 	service domain.Service
+	errs    ErrorRenderer
 	mon     monitoring.Monitoring
 	lg      logger.Logger
 }
@@ -20,41 +22,37 @@ type domainHandler struct {
 func NewDomainHandler(prov Provider) *domainHandler {
 	return &domainHandler{
 		prov.GetService(),
+		problem.New(prov),
 		prov.GetMonitoring(),
 		prov.GetLogger(),
 	}
 }
 
+type ObjectsResponse struct {
+	Data string `json:"data"`
+}
+
 func (h *domainHandler) GetObjects(w http.ResponseWriter, r *http.Request) {
 	req := domain.ServiceRequest{}
 
-	err := req.Validate()
+	err := binding.Bind(r, &req)
 	if err != nil {
-		h.lg.Error(err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.errs.Render(w, r, err)
 		return
 	}
 
 	err = h.service.Do(r.Context(), req)
 	if err != nil {
-		if errors.As(err, &domain.ErrNotFound) {
-			h.lg.Error("Error getting objects", err)
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
-		h.lg.Error("Error getting objects", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.errs.Render(w, r, err)
 		return
 	}
 
-	response := struct {
-		Data string `json:"data"`
-	}{}
+	response := ObjectsResponse{}
 
 	err = json.NewEncoder(w).Encode(&response)
 	if err != nil {
-		h.lg.Error("Error encoding response", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.lg.WithContext(r.Context()).Error("Error encoding response", err)
+		h.errs.Render(w, r, err)
 		return
 	}
 }
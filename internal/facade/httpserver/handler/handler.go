@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"go-clean-template/config"
 	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/listquery"
 	"go-clean-template/pkg/logger"
 	"go-clean-template/pkg/monitoring"
 	"net/http"
@@ -15,9 +18,21 @@ type handler struct {
 	mon     monitoring.Monitoring
 }
 
+type ErrorRenderer interface {
+	Render(w http.ResponseWriter, r *http.Request, err error)
+}
+
 type Provider interface {
 	GetService() domain.Service
+	GetJobService() domain.JobService
+	GetBackfillService() domain.BackfillService
 	GetAppVersion() string
+	GetAuditService() domain.AuditService
+	GetAuthService() domain.AuthService
+	GetPolicyService() domain.PolicyService
+	GetEnv() string
+	GetConfig() *config.Config
+	ReloadConfig(ctx context.Context) (*config.Config, error)
 	GetMonitoring() monitoring.Monitoring
 	GetLogger() logger.Logger
 }
@@ -58,3 +73,36 @@ func (h *handler) GetTimeInUTC(w http.ResponseWriter, _ *http.Request) {
 func (h *handler) GetNoContent(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func writeJSON(w http.ResponseWriter, r *http.Request, lg logger.Logger, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		lg.WithContext(r.Context()).Error("Error encoding response", err)
+	}
+}
+
+// writeList writes a page of rows with the cursor and the link of the next page
+func writeList[T any](w http.ResponseWriter, r *http.Request, lg logger.Logger, q listquery.Query, rows []T,
+	value func(row T, field string) any) {
+	rows, next := listquery.Page(q, rows, value)
+	res := listquery.NewResult(rows, next, r.URL)
+	if res.Next != "" {
+		w.Header().Set("Link", "<"+res.Next+`>; rel="next"`)
+	}
+	writeJSON(w, r, lg, http.StatusOK, res)
+}
+
+// GetErrorCatalog returns every error code the API may respond with and its message templates
+func (h *handler) GetErrorCatalog(w http.ResponseWriter, _ *http.Request) {
+	catalog := map[string]any{
+		"locales": domain.Locales(),
+		"codes":   domain.Catalog(),
+	}
+
+	err := json.NewEncoder(w).Encode(&catalog)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
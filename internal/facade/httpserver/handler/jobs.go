@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"go-clean-template/internal/domain"
+	"go-clean-template/internal/facade/httpserver/binding"
+	"go-clean-template/internal/facade/httpserver/problem"
+	"go-clean-template/pkg/listquery"
+	"go-clean-template/pkg/logger"
+	"net/http"
+)
+
+type jobHandler struct {
+	service domain.JobService
+	cursors *listquery.Signer
+	errs    ErrorRenderer
+	lg      logger.Logger
+}
+
+func NewJobHandler(prov Provider) *jobHandler {
+	return &jobHandler{
+		prov.GetJobService(),
+		listquery.NewSigner(prov.GetConfig().HTTP.Lists.CursorSecret),
+		problem.New(prov),
+		prov.GetLogger(),
+	}
+}
+
+// JobRunsRequest binds the job, the list parameters are parsed with JobRunList
+type JobRunsRequest struct {
+	Name string `path:"name" validate:"required"`
+}
+
+type TriggerJobRequest struct {
+	Name   string            `path:"name"    validate:"required"`
+	Params map[string]string `json:"params"`
+}
+
+func (h *jobHandler) List(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.service.Jobs(r.Context())
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, h.lg, http.StatusOK, jobs)
+}
+
+func (h *jobHandler) Runs(w http.ResponseWriter, r *http.Request) {
+	req := JobRunsRequest{}
+	err := binding.Bind(r, &req)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+	q, errs := listquery.Parse(r.URL.Query(), &domain.JobRunList, h.cursors)
+	if len(errs) > 0 {
+		h.errs.Render(w, r, domain.ValidationErrorOf(errs))
+		return
+	}
+
+	runs, err := h.service.Runs(r.Context(), req.Name, q)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+
+	writeList(w, r, h.lg, q, runs, domain.JobRun.ListValue)
+}
+
+func (h *jobHandler) Trigger(w http.ResponseWriter, r *http.Request) {
+	req := TriggerJobRequest{}
+	err := binding.Bind(r, &req)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+
+	run, err := h.service.Trigger(r.Context(), req.Name, req.Params)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, h.lg, http.StatusAccepted, run)
+}
+
+// State returns the job of the request with its last run, it is the audited state of triggers.
+// Unknown jobs have no state.
+func (h *jobHandler) State(r *http.Request) (any, error) {
+	req := JobRunsRequest{}
+	err := binding.BindParams(r, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := h.service.Jobs(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if job.Name == req.Name {
+			return job, nil
+		}
+	}
+	return nil, nil
+}
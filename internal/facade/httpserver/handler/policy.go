@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"go-clean-template/internal/domain"
+	"go-clean-template/internal/facade/httpserver/binding"
+	"go-clean-template/internal/facade/httpserver/problem"
+	"go-clean-template/pkg/logger"
+	"net/http"
+)
+
+type policyHandler struct {
+	service domain.PolicyService
+	errs    ErrorRenderer
+	lg      logger.Logger
+}
+
+func NewPolicyHandler(prov Provider) *policyHandler {
+	return &policyHandler{
+		prov.GetPolicyService(),
+		problem.New(prov),
+		prov.GetLogger(),
+	}
+}
+
+// Get returns the merged policy of config and database
+func (h *policyHandler) Get(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, h.lg, http.StatusOK, h.service.Policy())
+}
+
+func (h *policyHandler) Explain(w http.ResponseWriter, r *http.Request) {
+	req := domain.ExplainRequest{}
+	err := binding.Bind(r, &req)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+
+	d, err := h.service.Explain(r.Context(), req)
+	if err != nil {
+		h.errs.Render(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, h.lg, http.StatusOK, d)
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-clean-template/internal/domain"
+	"go-clean-template/internal/facade/httpserver/problem"
+	"go-clean-template/internal/facade/httpserver/sse"
+	"go-clean-template/pkg/logger"
+	"net/http"
+	"slices"
+)
+
+const StreamJobs = "jobs"
+
+type Broker interface {
+	Publish(name, event string, v any) error
+	Serve(w http.ResponseWriter, r *http.Request, name string) error
+}
+
+type ProblemWriter interface {
+	Write(w http.ResponseWriter, r *http.Request, status int, code domain.ErrorCode, params domain.Params)
+}
+
+type streamHandler struct {
+	broker Broker
+	errs   ProblemWriter
+	lg     logger.Logger
+}
+
+func NewStreamHandler(prov Provider, broker Broker) *streamHandler {
+	return &streamHandler{
+		broker,
+		problem.New(prov),
+		prov.GetLogger(),
+	}
+}
+
+// Forward publishes the relayed events to the stream, so clients of every instance get the events of
+// all of them. The data of an event is its JSON.
+func (h *streamHandler) Forward(relay domain.EventRelay, stream string, events ...string) {
+	relay.Listen(func(name string, payload []byte) {
+		if !slices.Contains(events, name) {
+			return
+		}
+		err := h.broker.Publish(stream, name, json.RawMessage(payload))
+		if err != nil {
+			h.lg.Error(fmt.Errorf("streamHandler.Forward: %w", err))
+		}
+	})
+}
+
+// Jobs streams started and finished job runs
+func (h *streamHandler) Jobs(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, StreamJobs)
+}
+
+func (h *streamHandler) serve(w http.ResponseWriter, r *http.Request, stream string) {
+	err := h.broker.Serve(w, r, stream)
+	switch {
+	case errors.Is(err, sse.ErrClosed):
+		h.errs.Write(w, r, http.StatusServiceUnavailable, domain.CodeStopping, nil)
+	case err != nil:
+		// the stream has started, a client that went away is not an error worth a problem
+		h.lg.WithContext(r.Context()).Debug(err)
+	}
+}
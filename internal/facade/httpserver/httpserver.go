@@ -6,64 +6,203 @@ import (
 	"go-clean-template/config"
 	"go-clean-template/internal/domain"
 	"go-clean-template/internal/facade/httpserver/router"
+	"go-clean-template/pkg/idempotency"
 	"go-clean-template/pkg/logger"
 	"go-clean-template/pkg/monitoring"
+	"go-clean-template/pkg/ratelimit"
 	"net"
 	"net/http"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"golang.org/x/sync/errgroup"
 )
 
 type httpServer struct {
 	srv           *http.Server
+	h2c           *http.Server
+	listeners     []config.Listener
+	certs         CertReloader
+	streams       StreamBroker
 	cancelBaseCtx context.CancelFunc
+	lg            logger.Logger
+}
+
+// StreamBroker holds the open event streams, they don't end on their own and are closed on stop
+type StreamBroker interface {
+	Close(ctx context.Context) error
 }
 
 type Provider interface {
 	GetService() domain.Service
+	GetJobService() domain.JobService
+	GetBackfillService() domain.BackfillService
 	GetAppVersion() string
+	GetAuditService() domain.AuditService
+	GetAuthService() domain.AuthService
+	GetPolicyService() domain.PolicyService
+	GetEnv() string
+	GetConfig() *config.Config
+	ReloadConfig(ctx context.Context) (*config.Config, error)
+	OnConfigReload(fn func(cfg *config.Config))
+	GetIdempotencyRepository() idempotency.Repository
+	GetRateLimitStore() ratelimit.Store
+	GetEventBus() domain.EventBus
+	GetEventRelay() domain.EventRelay
 	GetMonitoring() monitoring.Monitoring
 	GetLogger() logger.Logger
 }
 
-func New(cfg config.HTTP, prov Provider) *httpServer {
-	root := router.New(prov)
+// New creates the public server of business routes
+func New(cfg config.HTTP, prov Provider) (*httpServer, error) {
+	rt := router.New(cfg, prov)
+	srv, err := newServer(cfg, rt.Router(), rt.Streams(), prov.GetLogger())
+	if err != nil {
+		return nil, fmt.Errorf("httpserver.New: %w", err)
+	}
+	return srv, nil
+}
+
+// NewAdmin creates the server of pprof, metrics, probes and admin actions on its own port
+func NewAdmin(cfg config.Admin, prov Provider) (*httpServer, error) {
+	httpCfg := config.HTTP{
+		Port:         cfg.Port,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+		TLS:          cfg.TLS,
+	}
+	rt := router.NewAdmin(prov)
+	srv, err := newServer(httpCfg, rt.Router(), rt.Streams(), prov.GetLogger())
+	if err != nil {
+		return nil, fmt.Errorf("httpserver.NewAdmin: %w", err)
+	}
+	return srv, nil
+}
 
+func newServer(cfg config.HTTP, handler http.Handler, streams StreamBroker, lg logger.Logger) (*httpServer, error) {
 	srv := &http.Server{
 		Addr:         ":" + cfg.Port,
 		ReadTimeout:  cfg.ReadTimeout,
 		IdleTimeout:  cfg.IdleTimeout,
 		WriteTimeout: cfg.WriteTimeout,
-		Handler:      root.Router(),
+		Handler:      handler,
+	}
+
+	var certs CertReloader
+	if cfg.TLS.Enabled {
+		tlsCfg, rl, err := newTLSConfig(cfg.TLS, lg)
+		if err != nil {
+			return nil, err
+		}
+		srv.TLSConfig = tlsCfg
+		certs = rl
+	}
+
+	listeners := cfg.Listeners
+	if len(listeners) == 0 {
+		listeners = []config.Listener{{Network: networkTCP, Address: srv.Addr, TLS: cfg.TLS.Enabled}}
+	}
+	err := validateListeners(listeners, cfg.TLS.Enabled)
+	if err != nil {
+		return nil, err
+	}
+
+	var h2cSrv *http.Server
+	if slices.ContainsFunc(listeners, func(l config.Listener) bool { return l.H2C }) {
+		h2cSrv = &http.Server{
+			ReadTimeout:  cfg.ReadTimeout,
+			IdleTimeout:  cfg.IdleTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			Handler:      h2c.NewHandler(handler, &http2.Server{IdleTimeout: cfg.IdleTimeout}),
+		}
 	}
 
 	return &httpServer{
 		srv,
+		h2cSrv,
+		listeners,
+		certs,
+		streams,
 		nil,
-	}
+		lg,
+	}, nil
 }
 
+// Run serves the router on every listener and returns when all of them are closed
 func (h *httpServer) Run(ctx context.Context) error {
 	baseCtx, cancel := context.WithCancel(ctx)
 	h.cancelBaseCtx = cancel
 
-	h.srv.BaseContext = func(_ net.Listener) context.Context {
-		return baseCtx
+	for _, srv := range h.servers() {
+		srv.BaseContext = func(_ net.Listener) context.Context {
+			return baseCtx
+		}
+	}
+
+	bindings, err := h.listen()
+	if err != nil {
+		return fmt.Errorf("httpServer.Run: %w", err)
+	}
+
+	if h.certs != nil {
+		go h.certs.Run(baseCtx)
 	}
 
-	return h.srv.ListenAndServe()
+	eg := errgroup.Group{}
+	for _, b := range bindings {
+		eg.Go(func() error {
+			h.lg.Info(fmt.Sprintf("http server listens on %s %s", b.ln.Addr().Network(), b.ln.Addr()))
+			if b.tls {
+				// the certificate is taken from TLSConfig.GetCertificate
+				return b.srv.ServeTLS(b.ln, "", "")
+			}
+			return b.srv.Serve(b.ln)
+		})
+	}
+	return eg.Wait()
 }
 
 func (h *httpServer) Stop(ctx context.Context) error {
-	h.srv.SetKeepAlivesEnabled(false)
 	h.cancelBaseCtx()
 
-	err := h.srv.Shutdown(ctx)
-	if err != nil {
-		return fmt.Errorf("h.srv.Shutdown: %w", err)
+	eg := errgroup.Group{}
+	if h.streams != nil {
+		// Shutdown waits for active requests, open streams are closed so it doesn't wait for them
+		eg.Go(func() error {
+			err := h.streams.Close(ctx)
+			if err != nil {
+				return fmt.Errorf("streams.Close: %w", err)
+			}
+			return nil
+		})
 	}
-
-	return nil
+	for _, srv := range h.servers() {
+		eg.Go(func() error {
+			srv.SetKeepAlivesEnabled(false)
+			err := srv.Shutdown(ctx)
+			if err != nil {
+				return fmt.Errorf("srv.Shutdown: %w", err)
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
 }
 
 func (h *httpServer) Info() string {
-	return h.srv.Addr
+	addrs := make([]string, 0, len(h.listeners))
+	for _, l := range h.listeners {
+		addrs = append(addrs, l.Network+":"+l.Address)
+	}
+	return strings.Join(addrs, ", ")
+}
+
+func (h *httpServer) servers() []*http.Server {
+	if h.h2c == nil {
+		return []*http.Server{h.srv}
+	}
+	return []*http.Server{h.srv, h.h2c}
 }
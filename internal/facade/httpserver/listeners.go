@@ -0,0 +1,125 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"go-clean-template/config"
+	"go-clean-template/pkg/sdlisten"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+)
+
+const (
+	networkTCP     = "tcp"
+	networkUnix    = "unix"
+	networkSystemd = "systemd"
+)
+
+type binding struct {
+	srv *http.Server
+	ln  net.Listener
+	tls bool
+}
+
+func validateListeners(listeners []config.Listener, tlsEnabled bool) error {
+	for _, l := range listeners {
+		switch l.Network {
+		case networkTCP, networkUnix, networkSystemd:
+		default:
+			return fmt.Errorf("listener %q: unknown network %q", l.Address, l.Network)
+		}
+		if l.TLS && l.H2C {
+			return fmt.Errorf("listener %s:%s: h2c can't be served over tls", l.Network, l.Address)
+		}
+		if l.TLS && !tlsEnabled {
+			return fmt.Errorf("listener %s:%s: tls is not enabled", l.Network, l.Address)
+		}
+		if l.SocketMode != "" {
+			if _, err := strconv.ParseUint(l.SocketMode, 8, 32); err != nil {
+				return fmt.Errorf("listener %s:%s: socket-mode: %w", l.Network, l.Address, err)
+			}
+		}
+	}
+	return nil
+}
+
+// listen opens every configured listener, the opened ones are closed if any fails
+func (h *httpServer) listen() ([]binding, error) {
+	var activated []sdlisten.Listener
+	var err error
+	if slices.ContainsFunc(h.listeners, func(l config.Listener) bool { return l.Network == networkSystemd }) {
+		activated, err = sdlisten.Listeners()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bindings := make([]binding, 0, len(h.listeners))
+	closeAll := func() {
+		for _, b := range bindings {
+			_ = b.ln.Close()
+		}
+	}
+
+	for _, l := range h.listeners {
+		srv := h.srv
+		if l.H2C {
+			srv = h.h2c
+		}
+
+		var lns []net.Listener
+		switch l.Network {
+		case networkTCP:
+			ln, err := net.Listen(networkTCP, l.Address)
+			if err != nil {
+				closeAll()
+				return nil, fmt.Errorf("net.Listen: %w", err)
+			}
+			lns = append(lns, ln)
+		case networkUnix:
+			ln, err := listenUnix(l.Address, l.SocketMode)
+			if err != nil {
+				closeAll()
+				return nil, err
+			}
+			lns = append(lns, ln)
+		case networkSystemd:
+			for _, a := range activated {
+				if l.Address == "" || a.Name == l.Address {
+					lns = append(lns, a.Listener)
+				}
+			}
+			if len(lns) == 0 {
+				closeAll()
+				return nil, fmt.Errorf("no socket %q is passed by systemd", l.Address)
+			}
+		}
+
+		for _, ln := range lns {
+			bindings = append(bindings, binding{srv, ln, l.TLS})
+		}
+	}
+	return bindings, nil
+}
+
+func listenUnix(path, mode string) (net.Listener, error) {
+	// a socket file left by a killed process makes Listen fail
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("os.Remove: %w", err)
+	}
+	ln, err := net.Listen(networkUnix, path)
+	if err != nil {
+		return nil, fmt.Errorf("net.Listen: %w", err)
+	}
+	if mode != "" {
+		perm, _ := strconv.ParseUint(mode, 8, 32)
+		if err := os.Chmod(path, os.FileMode(perm)); err != nil {
+			_ = ln.Close()
+			return nil, fmt.Errorf("os.Chmod: %w", err)
+		}
+	}
+	return ln, nil
+}
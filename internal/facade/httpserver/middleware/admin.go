@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"go-clean-template/config"
+	"go-clean-template/internal/domain"
+	"net/http"
+)
+
+// actorOperator is the kind of actors named by the actor header of the admin listener
+const actorOperator = "operator"
+
+// ApplyAdmin replaces the actor settings of the admin listener, it is called on config reload
+func (m *middleware) ApplyAdmin(cfg config.Admin) {
+	m.admin.Store(&cfg)
+}
+
+// AdminActorMiddleware sets the audit actor of admin requests, the admin listener has no authentication.
+// The actor is the common name of a verified client certificate or the value of the actor header,
+// it must run after ClientCertMiddleware.
+func (m *middleware) AdminActorMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := m.admin.Load()
+		actor := ""
+		if cert, ok := domain.ClientCertFromContext(r.Context()); ok && cert.Verified && cert.CommonName != "" {
+			actor = domain.Principal{Subject: cert.CommonName, Method: domain.AuthMethodClientCert}.Actor()
+		} else if v := r.Header.Get(cfg.ActorHeader); cfg.ActorHeader != "" && v != "" {
+			actor = actorOperator + ":" + v
+		}
+
+		if actor == "" {
+			if cfg.RequireActor && !isSafeMethod(r.Method) {
+				m.errs.Render(w, r, domain.NewUnauthorizedError(domain.CodeAuthRequired, nil))
+				return
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(domain.ContextWithActor(r.Context(), actor)))
+	})
+}
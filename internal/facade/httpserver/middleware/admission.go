@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-clean-template/config"
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/admission"
+	"go-clean-template/pkg/logger"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	admissionMetrics  = "admission"
+	admissionInFlight = "admission_in_flight"
+	admissionQueued   = "admission_queued"
+	admissionLimit    = "admission_limit"
+)
+
+type admissionGroup struct {
+	name       string
+	prefixes   []string
+	ctrl       *admission.Controller
+	priorities map[string]admission.Priority
+}
+
+func newAdmissionGroups(cfg config.Admission, lg logger.Logger) []admissionGroup {
+	if !cfg.Enabled {
+		return nil
+	}
+	groups := make([]admissionGroup, 0, len(cfg.Groups))
+	for _, g := range cfg.Groups {
+		group := admissionGroup{
+			name:     g.Name,
+			prefixes: g.Prefixes,
+			ctrl: admission.New(admission.Options{
+				InitialLimit:  g.InitialLimit,
+				MinLimit:      g.MinLimit,
+				MaxLimit:      g.MaxLimit,
+				TargetLatency: g.TargetLatency,
+				Backoff:       g.Backoff,
+				MaxQueue:      g.MaxQueue,
+				QueueTimeout:  g.QueueTimeout,
+			}),
+			priorities: make(map[string]admission.Priority),
+		}
+		for _, rt := range g.Routes {
+			p, err := admission.ParsePriority(rt.Priority)
+			if err != nil {
+				lg.Error(fmt.Errorf("admission group %s route %s %s: %w", g.Name, rt.Method, rt.Path, err))
+				continue
+			}
+			group.priorities[strings.ToUpper(rt.Method)+" "+rt.Path] = p
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func (g *admissionGroup) priority(r *http.Request) admission.Priority {
+	if p, ok := g.priorities[r.Method+" "+routeTemplate(r)]; ok {
+		return p
+	}
+	if _, ok := domain.PrincipalFromContext(r.Context()); ok {
+		return admission.PriorityNormal
+	}
+	return admission.PriorityLow
+}
+
+// AdmissionMiddleware holds requests over the concurrency limit of their group in a priority queue.
+// Requests that can't be admitted in time get 503 so clients and balancers retry elsewhere.
+// Routes without a timeout, like streams, are not admitted: they would hold a slot while they are open
+// and skew the latency the limit adapts to.
+func (m *middleware) AdmissionMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g := m.admissionGroup(r.URL.Path)
+		if l, ok := m.routeLimits[r.Method+" "+routeTemplate(r)]; g == nil || ok && l.timeout < 0 {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		done, err := g.ctrl.Acquire(r.Context(), g.priority(r))
+		m.setAdmissionGauges(g)
+		if err != nil {
+			m.mon.Count(admissionMetrics, g.name+": "+admissionReason(err), true)
+			w.Header().Set("Retry-After", "1")
+			m.errs.Write(w, r, http.StatusServiceUnavailable, domain.CodeOverloaded, domain.Params{"group": g.name})
+			return
+		}
+		m.mon.Count(admissionMetrics, g.name, false)
+
+		start := time.Now()
+		defer func() {
+			done(time.Since(start))
+			m.setAdmissionGauges(g)
+		}()
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (m *middleware) admissionGroup(path string) *admissionGroup {
+	for i := range m.admission {
+		for _, prefix := range m.admission[i].prefixes {
+			if strings.HasPrefix(path, prefix) {
+				return &m.admission[i]
+			}
+		}
+	}
+	return nil
+}
+
+func (m *middleware) setAdmissionGauges(g *admissionGroup) {
+	m.mon.Set(admissionInFlight, g.name, float64(g.ctrl.InFlight()))
+	m.mon.Set(admissionQueued, g.name, float64(g.ctrl.Queued()))
+	m.mon.Set(admissionLimit, g.name, float64(g.ctrl.Limit()))
+}
+
+func admissionReason(err error) string {
+	switch {
+	case errors.Is(err, admission.ErrQueueFull):
+		return "queue_full"
+	case errors.Is(err, admission.ErrShed):
+		return "shed"
+	case errors.Is(err, admission.ErrTimeout):
+		return "timeout"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	}
+	return "unknown"
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/logger"
+	"hash"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// resourceVars are route variables that identify the changed resource, the first present wins
+var resourceVars = []string{"id", "name"} //nolint:gochecknoglobals //route vars
+
+// AuditState loads the state of the resource changed by the request, nil if the resource doesn't exist
+type AuditState func(r *http.Request) (any, error)
+
+type auditRecorder struct {
+	w          http.ResponseWriter
+	statusCode int
+	hash       hash.Hash
+}
+
+func (r *auditRecorder) Header() http.Header {
+	return r.w.Header()
+}
+
+func (r *auditRecorder) Write(b []byte) (int, error) {
+	if r.statusCode == 0 {
+		r.statusCode = http.StatusOK
+	}
+	r.hash.Write(b)
+	return r.w.Write(b)
+}
+
+func (r *auditRecorder) WriteHeader(statusCode int) {
+	if r.statusCode == 0 {
+		r.statusCode = statusCode
+	}
+	r.w.WriteHeader(statusCode)
+}
+
+// AuditRoute sets the loader of the state hashed before and after state-changing requests of the route.
+// Routes are registered before the server starts, so routeAudits is not guarded.
+func (m *middleware) AuditRoute(method, path string, state func(r *http.Request) (any, error)) {
+	m.routeAudits[strings.ToUpper(method)+" "+path] = state
+}
+
+// AuditMiddleware appends an audit entry for every state-changing request. Routes with a state loader
+// record the hashes of the resource before and after the request, routes without one create resources:
+// they have no before state and the created resource of a successful response is the after state.
+func (m *middleware) AuditMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isSafeMethod(r.Method) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+		route := routeTemplate(r)
+		state := m.routeAudits[r.Method+" "+route]
+
+		rr := &auditRecorder{w: w, hash: sha256.New()}
+		entry := domain.AuditEntry{
+			Actor:      requestActor(r),
+			Action:     domain.AuditActionRequest,
+			Method:     r.Method,
+			Route:      route,
+			ResourceID: resourceID(r),
+			BeforeHash: m.stateHash(r, state),
+			RequestID:  logger.RequestIDFromContext(ctx),
+			Outcome:    domain.AuditOutcomeFailure,
+		}
+		// recorded on panic too, the panic is passed on to RecoverMiddleware
+		defer func() {
+			entry.Status = rr.statusCode
+			success := rr.statusCode > 0 && rr.statusCode < http.StatusBadRequest
+			if success {
+				entry.Outcome = domain.AuditOutcomeSuccess
+			}
+			switch {
+			case state != nil:
+				entry.AfterHash = m.stateHash(r, state)
+			case success:
+				entry.AfterHash = hex.EncodeToString(rr.hash.Sum(nil))
+			}
+			if err := m.audit.Record(ctx, entry); err != nil {
+				m.lg.WithContext(ctx).Error(fmt.Errorf("audit: %w", err))
+			}
+		}()
+
+		h.ServeHTTP(rr, r)
+	})
+}
+
+// stateHash hashes the state loaded by state, it is empty if the route has no loader or the resource doesn't exist
+func (m *middleware) stateHash(r *http.Request, state AuditState) string {
+	if state == nil {
+		return ""
+	}
+	v, err := state(r)
+	if err == nil {
+		var sum string
+		sum, err = domain.StateHash(v)
+		if err == nil {
+			return sum
+		}
+	}
+	var notFound domain.NotFoundError
+	var invalid domain.ValidationError
+	if !errors.As(err, &notFound) && !errors.As(err, &invalid) {
+		m.lg.WithContext(r.Context()).Error(fmt.Errorf("audit state: %w", err))
+	}
+	return ""
+}
+
+func requestActor(r *http.Request) string {
+	actor := domain.ActorFromContext(r.Context())
+	if actor != domain.ActorSystem {
+		return actor
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "anonymous@" + host
+}
+
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return r.URL.Path
+}
+
+func resourceID(r *http.Request) string {
+	vars := mux.Vars(r)
+	for _, name := range resourceVars {
+		if v, ok := vars[name]; ok {
+			return v
+		}
+	}
+	return ""
+}
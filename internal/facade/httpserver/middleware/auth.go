@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"go-clean-template/config"
+	"go-clean-template/internal/domain"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultAPIKeyHeader = "X-API-Key"
+	bearerPrefix        = "Bearer "
+	wwwAuthenticate     = `Bearer realm="api"`
+)
+
+// Authenticator checks one kind of credentials
+type Authenticator interface {
+	// Authenticate returns false if the request carries no credentials of its kind
+	Authenticate(r *http.Request) (domain.Principal, bool, error)
+}
+
+type bearerAuthenticator struct {
+	auth domain.AuthService
+}
+
+func (a bearerAuthenticator) Authenticate(r *http.Request) (domain.Principal, bool, error) {
+	header := r.Header.Get("Authorization")
+	if len(header) < len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+		return domain.Principal{}, false, nil
+	}
+	p, err := a.auth.AuthenticateBearer(r.Context(), strings.TrimSpace(header[len(bearerPrefix):]))
+	return p, true, err
+}
+
+type apiKeyAuthenticator struct {
+	auth   domain.AuthService
+	header string
+}
+
+func (a apiKeyAuthenticator) Authenticate(r *http.Request) (domain.Principal, bool, error) {
+	key := r.Header.Get(a.header)
+	if key == "" {
+		return domain.Principal{}, false, nil
+	}
+	p, err := a.auth.AuthenticateAPIKey(r.Context(), key)
+	return p, true, err
+}
+
+// clientCertAuthenticator accepts client certificates verified by the TLS handshake, they carry no scopes
+type clientCertAuthenticator struct{}
+
+func (clientCertAuthenticator) Authenticate(r *http.Request) (domain.Principal, bool, error) {
+	cert, ok := domain.ClientCertFromContext(r.Context())
+	if !ok || !cert.Verified {
+		return domain.Principal{}, false, nil
+	}
+	return domain.Principal{Subject: cert.Subject, Method: domain.AuthMethodClientCert, Scopes: []string{},
+		KeyID: cert.SerialNumber}, true, nil
+}
+
+func newAuthenticators(cfg config.Auth, auth domain.AuthService) []Authenticator {
+	if !cfg.Enabled || auth == nil {
+		return nil
+	}
+	res := make([]Authenticator, 0)
+	if cfg.JWT.Enabled {
+		res = append(res, bearerAuthenticator{auth})
+	}
+	if cfg.APIKeys.Enabled {
+		header := cfg.APIKeys.Header
+		if header == "" {
+			header = defaultAPIKeyHeader
+		}
+		res = append(res, apiKeyAuthenticator{auth, header})
+	}
+	return append(res, clientCertAuthenticator{})
+}
+
+// AuthMiddleware puts the principal of the first authenticator that finds credentials into the context.
+// Requests without credentials pass as anonymous, routes reject them in Authorize.
+func (m *middleware) AuthMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, a := range m.authenticators {
+			p, ok, err := a.Authenticate(r)
+			if !ok {
+				continue
+			}
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", wwwAuthenticate)
+				m.errs.Render(w, r, err)
+				return
+			}
+			r = r.WithContext(domain.ContextWithPrincipal(r.Context(), p))
+			break
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Authorize guards a route: public routes pass, others need a principal with every scope
+// and the permission bound to the route by the policy
+func (m *middleware) Authorize(method, path string, public bool, scopes []string, h http.Handler) http.Handler {
+	if !m.cfg.Auth.Enabled || public {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := domain.PrincipalFromContext(r.Context())
+		if !ok {
+			w.Header().Set("WWW-Authenticate", wwwAuthenticate)
+			m.errs.Render(w, r, domain.NewUnauthorizedError(domain.CodeAuthRequired, nil))
+			return
+		}
+		if !p.HasScopes(scopes...) {
+			m.errs.Render(w, r, domain.NewForbiddenError(domain.CodeForbiddenNoScopes,
+				domain.Params{"scopes": strings.Join(scopes, ", ")}))
+			return
+		}
+		if m.policy.Enabled() {
+			// the binding is looked up per request, the policy can change at runtime
+			if perm, bound := m.policy.RoutePermission(method, path); bound {
+				d := m.policy.Decide(r.Context(), p, perm, "")
+				if !d.Allowed {
+					m.errs.Render(w, r, domain.NewForbiddenError(domain.CodeForbiddenPermission,
+						domain.Params{"permission": d.Permission}))
+					return
+				}
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,236 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/httpcache"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type routeCache struct {
+	cacheControl string
+	ttl          time.Duration
+}
+
+type cacheEntry struct {
+	header   http.Header
+	body     []byte
+	storedAt time.Time
+	expires  time.Time
+}
+
+// responseStore is the server cache, keys start with the method and the template of the route
+type responseStore interface {
+	Get(key string) (cacheEntry, bool)
+	Set(key string, value cacheEntry)
+	Delete(key string)
+	Len() int
+	Keys() []string
+}
+
+// CacheRoute wraps the handler of a GET route: it adds ETag and Cache-Control to successful responses and
+// answers conditional requests with 304 Not Modified. Responses of routes with a TTL are served from the
+// server cache, events of invalidateOn drop them. The route guard must wrap the returned handler, so
+// a stored response is only served to callers that may see it.
+func (m *middleware) CacheRoute(method, path, cacheControl string, ttl time.Duration, invalidateOn []string,
+	h http.Handler) http.Handler {
+	if !m.cfg.Cache.Enabled {
+		return h
+	}
+	route := strings.ToUpper(method) + " " + path
+	rc := routeCache{cacheControl: cacheControl, ttl: ttl}
+	if ttl > 0 && m.responses != nil && m.events != nil {
+		for _, name := range invalidateOn {
+			m.events.Subscribe(name, "http cache "+route, domain.DeliverySync,
+				func(context.Context, domain.Event) error {
+					m.invalidate(route)
+					return nil
+				})
+		}
+	}
+	return m.cache(route, rc, h)
+}
+
+func (m *middleware) invalidate(route string) {
+	for _, key := range m.responses.Keys() {
+		if strings.HasPrefix(key, route+" ") {
+			m.responses.Delete(key)
+		}
+	}
+}
+
+// cacheKey starts with the route, responses are stored per principal: they may depend on it
+func cacheKey(route string, r *http.Request) string {
+	key := route + " " + r.URL.Query().Encode() + " " + r.Header.Get("Accept-Language")
+	if p, ok := domain.PrincipalFromContext(r.Context()); ok {
+		key += " " + p.Actor()
+	}
+	return key
+}
+
+func (m *middleware) cache(route string, rc routeCache, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := ""
+		if rc.ttl > 0 && m.responses != nil {
+			key = cacheKey(route, r)
+			// no-cache asks to validate with the origin, the fresh response replaces the stored one
+			if !strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+				if e, ok := m.responses.Get(key); ok && time.Now().Before(e.expires) {
+					writeCached(w, r, e)
+					return
+				}
+			}
+		}
+
+		bw := &bufferedWriter{w: w, limit: m.cfg.Cache.MaxBodySize}
+		h.ServeHTTP(bw, r)
+		if bw.streaming {
+			return
+		}
+
+		hdr := w.Header()
+		if rc.cacheControl != "" && hdr.Get("Cache-Control") == "" &&
+			(bw.statusCode == http.StatusOK || bw.statusCode == http.StatusNotModified) {
+			hdr.Set("Cache-Control", rc.cacheControl)
+		}
+		if bw.statusCode != http.StatusOK {
+			_ = bw.send()
+			return
+		}
+
+		etag := hdr.Get("ETag")
+		if etag == "" {
+			etag = httpcache.ETag(bw.buf.Bytes())
+			if m.cfg.Cache.WeakETags {
+				etag = "W/" + etag
+			}
+			hdr.Set("ETag", etag)
+		}
+		if key != "" {
+			m.store(key, rc.ttl, hdr, bw.buf.Bytes())
+		}
+
+		lastModified, _ := http.ParseTime(hdr.Get("Last-Modified"))
+		if httpcache.Fresh(r, etag, lastModified) {
+			httpcache.WriteNotModified(w)
+			return
+		}
+		if err := bw.send(); err != nil {
+			m.lg.WithContext(r.Context()).Error(err)
+		}
+	})
+}
+
+// store keeps the response unless it is personal or the cache is full of fresh entries
+func (m *middleware) store(key string, ttl time.Duration, hdr http.Header, body []byte) {
+	cc := hdr.Get("Cache-Control")
+	if hdr.Get("Set-Cookie") != "" || strings.Contains(cc, "no-store") || strings.Contains(cc, "private") {
+		return
+	}
+	if limit := m.cfg.Cache.MaxEntries; limit > 0 && m.responses.Len() >= limit {
+		now := time.Now()
+		for _, k := range m.responses.Keys() {
+			if e, ok := m.responses.Get(k); ok && now.After(e.expires) {
+				m.responses.Delete(k)
+			}
+		}
+		if m.responses.Len() >= limit {
+			return
+		}
+	}
+	now := time.Now()
+	m.responses.Set(key, cacheEntry{
+		header:   hdr.Clone(),
+		body:     bytes.Clone(body),
+		storedAt: now,
+		expires:  now.Add(ttl),
+	})
+}
+
+func writeCached(w http.ResponseWriter, r *http.Request, e cacheEntry) {
+	hdr := w.Header()
+	// headers of this request, like X-Request-ID, are kept
+	for k, v := range e.header {
+		if _, ok := hdr[k]; !ok {
+			hdr[k] = v
+		}
+	}
+	hdr.Set("Age", strconv.Itoa(int(time.Since(e.storedAt).Seconds())))
+
+	lastModified, _ := http.ParseTime(hdr.Get("Last-Modified"))
+	if httpcache.Fresh(r, hdr.Get("ETag"), lastModified) {
+		httpcache.WriteNotModified(w)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(e.body)
+}
+
+// bufferedWriter holds the response back until the middleware has looked at it. A flushed response is
+// a stream and a response larger than limit is too big to hold, both are written through.
+type bufferedWriter struct {
+	w          http.ResponseWriter
+	statusCode int
+	buf        bytes.Buffer
+	limit      int
+	streaming  bool
+}
+
+func (bw *bufferedWriter) Header() http.Header {
+	return bw.w.Header()
+}
+
+func (bw *bufferedWriter) WriteHeader(statusCode int) {
+	if bw.streaming {
+		bw.w.WriteHeader(statusCode)
+		return
+	}
+	if bw.statusCode == 0 {
+		bw.statusCode = statusCode
+	}
+}
+
+func (bw *bufferedWriter) Write(b []byte) (int, error) {
+	if bw.streaming {
+		return bw.w.Write(b)
+	}
+	if bw.statusCode == 0 {
+		bw.statusCode = http.StatusOK
+	}
+	if bw.limit > 0 && bw.buf.Len()+len(b) > bw.limit {
+		bw.streaming = true
+		if err := bw.send(); err != nil {
+			return 0, err
+		}
+		return bw.w.Write(b)
+	}
+	return bw.buf.Write(b)
+}
+
+func (bw *bufferedWriter) Flush() {
+	if !bw.streaming {
+		bw.streaming = true
+		_ = bw.send()
+	}
+	if f, ok := bw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (bw *bufferedWriter) Unwrap() http.ResponseWriter {
+	return bw.w
+}
+
+func (bw *bufferedWriter) send() error {
+	if bw.statusCode == 0 {
+		return nil
+	}
+	bw.w.WriteHeader(bw.statusCode)
+	_, err := bw.w.Write(bw.buf.Bytes())
+	bw.buf.Reset()
+	return err
+}
@@ -0,0 +1,283 @@
+package middleware
+
+import (
+	"fmt"
+	"go-clean-template/config"
+	"go-clean-template/pkg/logger"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingZstd    = "zstd"
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+type encoder interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+	Flush() error
+}
+
+// compression keeps a pool of encoders per encoding in the order of preference
+type compression struct {
+	encodings []string
+	pools     map[string]*sync.Pool
+	minSize   int
+	types     []string
+}
+
+func newCompression(cfg config.Compression, lg logger.Logger) *compression {
+	c := &compression{pools: make(map[string]*sync.Pool), minSize: cfg.MinSize, types: cfg.ContentTypes}
+	if !cfg.Enabled {
+		return c
+	}
+	for _, name := range cfg.Encodings {
+		newEncoder, err := encoderFactory(strings.ToLower(name), cfg.Level)
+		if err != nil {
+			lg.Error(fmt.Errorf("compression: %w", err))
+			continue
+		}
+		c.encodings = append(c.encodings, strings.ToLower(name))
+		c.pools[strings.ToLower(name)] = &sync.Pool{New: func() any { return newEncoder() }}
+	}
+	return c
+}
+
+// encoderFactory checks the level once, so pools can create encoders without errors
+func encoderFactory(name string, level int) (func() encoder, error) {
+	switch name {
+	case encodingGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+			return nil, err
+		}
+		return func() encoder {
+			w, _ := gzip.NewWriterLevel(io.Discard, level)
+			return w
+		}, nil
+	case encodingDeflate:
+		if level == 0 {
+			level = flate.DefaultCompression
+		}
+		if _, err := flate.NewWriter(io.Discard, level); err != nil {
+			return nil, err
+		}
+		return func() encoder {
+			w, _ := flate.NewWriter(io.Discard, level)
+			return w
+		}, nil
+	case encodingZstd:
+		opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+		if level != 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		if _, err := zstd.NewWriter(nil, opts...); err != nil {
+			return nil, err
+		}
+		return func() encoder {
+			w, _ := zstd.NewWriter(nil, opts...)
+			return w
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown encoding %s", name)
+}
+
+// negotiate picks the accepted encoding with the highest q-value, "" means identity
+func (c *compression) negotiate(acceptEncoding string) string {
+	qs := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				q = 0
+			}
+		}
+		qs[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+	best, bestQ := "", 0.0
+	for _, enc := range c.encodings {
+		q, ok := qs[enc]
+		if !ok {
+			q = qs["*"]
+		}
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+func (c *compression) allowed(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	// streams must reach the client as they are written
+	if mediaType == "" || mediaType == "text/event-stream" {
+		return false
+	}
+	for _, t := range c.types {
+		if prefix, ok := strings.CutSuffix(t, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if strings.EqualFold(mediaType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter holds the response back until MinSize bytes are written, then it sends the header
+// with or without Content-Encoding. Responses that end or flush before that are sent as they are.
+type compressWriter struct {
+	w          http.ResponseWriter
+	c          *compression
+	encoding   string
+	statusCode int
+	buf        []byte
+	started    bool
+	enc        encoder
+}
+
+func (cw *compressWriter) Header() http.Header {
+	return cw.w.Header()
+}
+
+func (cw *compressWriter) WriteHeader(statusCode int) {
+	if cw.started || cw.statusCode != 0 {
+		return
+	}
+	if statusCode < http.StatusOK {
+		cw.w.WriteHeader(statusCode)
+		return
+	}
+	cw.statusCode = statusCode
+	if statusCode == http.StatusNoContent || statusCode == http.StatusNotModified {
+		cw.start(false)
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.statusCode == 0 {
+		cw.statusCode = http.StatusOK
+	}
+	if !cw.started {
+		cw.buf = append(cw.buf, b...)
+		if len(cw.buf) < cw.c.minSize {
+			return len(b), nil
+		}
+		return len(b), cw.start(true)
+	}
+	if cw.enc != nil {
+		return cw.enc.Write(b)
+	}
+	return cw.w.Write(b)
+}
+
+func (cw *compressWriter) start(compress bool) error {
+	cw.started = true
+	h := cw.w.Header()
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		// net/http would sniff the compressed bytes
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+	if cw.c.allowed(h.Get("Content-Type")) {
+		if !slices.Contains(h.Values("Vary"), "Accept-Encoding") {
+			h.Add("Vary", "Accept-Encoding")
+		}
+		compress = compress && cw.encoding != "" && h.Get("Content-Encoding") == "" &&
+			cw.statusCode != http.StatusPartialContent && !strings.Contains(h.Get("Cache-Control"), "no-transform")
+	} else {
+		compress = false
+	}
+	if compress {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		// the representation changes, a strong validator of the identity one can't be used
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		cw.enc = cw.c.pools[cw.encoding].Get().(encoder) //nolint:forcetypeassert //pool of encoders
+		cw.enc.Reset(cw.w)
+	}
+	cw.w.WriteHeader(cw.statusCode)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(buf)
+	} else {
+		_, err = cw.w.Write(buf)
+	}
+	return err
+}
+
+// Flush sends the response without compression if it has not started, a flushed response is a stream
+func (cw *compressWriter) Flush() {
+	if !cw.started {
+		if cw.statusCode == 0 {
+			cw.statusCode = http.StatusOK
+		}
+		_ = cw.start(false)
+	}
+	if cw.enc != nil {
+		_ = cw.enc.Flush()
+	}
+	if f, ok := cw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.w
+}
+
+func (cw *compressWriter) close() error {
+	if !cw.started && cw.statusCode != 0 {
+		if err := cw.start(false); err != nil {
+			return err
+		}
+	}
+	if cw.enc == nil {
+		return nil
+	}
+	err := cw.enc.Close()
+	cw.enc.Reset(io.Discard)
+	cw.c.pools[cw.encoding].Put(cw.enc)
+	cw.enc = nil
+	return err
+}
+
+// CompressionMiddleware must run outside of RequestLogger and MonitoringMiddleware, they see the plain response
+func (m *middleware) CompressionMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(m.compression.encodings) == 0 || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{w: w, c: m.compression, encoding: m.compression.negotiate(r.Header.Get("Accept-Encoding"))}
+		defer func() {
+			if err := cw.close(); err != nil {
+				m.lg.WithContext(r.Context()).Error(fmt.Errorf("compression: %w", err))
+			}
+		}()
+		h.ServeHTTP(cw, r)
+	})
+}
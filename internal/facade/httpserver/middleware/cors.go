@@ -1,19 +1,80 @@
 package middleware
 
 import (
+	"cmp"
+	"fmt"
+	"go-clean-template/config"
+	"go-clean-template/pkg/logger"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/rs/cors"
 )
 
-func (m *middleware) CorsMiddleware(h http.Handler) http.Handler {
-	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowCredentials: true,
-		AllowedHeaders:   []string{"*"},
-		Debug:            false,
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH"},
+type corsRoute struct {
+	prefix string
+	c      *cors.Cors
+}
+
+// corsPolicy holds the route rules longest prefix first, def is nil if CORS is disabled
+type corsPolicy struct {
+	def    *cors.Cors
+	routes []corsRoute
+}
+
+func newCORSPolicy(cfg config.CORS, lg logger.Logger) *corsPolicy {
+	if !cfg.Enabled {
+		return &corsPolicy{}
+	}
+	pol := &corsPolicy{def: newCORS("default", cfg.CORSRule, lg)}
+	for _, rt := range cfg.Routes {
+		pol.routes = append(pol.routes, corsRoute{rt.Prefix, newCORS(rt.Prefix, rt.CORSRule, lg)})
+	}
+	slices.SortFunc(pol.routes, func(a, b corsRoute) int {
+		return cmp.Compare(len(b.prefix), len(a.prefix))
 	})
+	return pol
+}
 
-	return c.Handler(h)
+func newCORS(name string, rule config.CORSRule, lg logger.Logger) *cors.Cors {
+	if rule.AllowCredentials && slices.Contains(rule.AllowedOrigins, "*") {
+		// browsers reject credentials with "Access-Control-Allow-Origin: *"
+		lg.Error(fmt.Errorf("cors %s: credentials are not allowed for origin *", name))
+		rule.AllowCredentials = false
+	}
+	return cors.New(cors.Options{
+		AllowedOrigins:   rule.AllowedOrigins,
+		AllowedMethods:   rule.AllowedMethods,
+		AllowedHeaders:   rule.AllowedHeaders,
+		ExposedHeaders:   rule.ExposedHeaders,
+		MaxAge:           int(rule.MaxAge.Seconds()),
+		AllowCredentials: rule.AllowCredentials,
+	})
+}
+
+func (p *corsPolicy) rule(path string) *cors.Cors {
+	for _, rt := range p.routes {
+		if strings.HasPrefix(path, rt.prefix) {
+			return rt.c
+		}
+	}
+	return p.def
+}
+
+// ApplyCORS replaces the CORS policy, it is called on config reload
+func (m *middleware) ApplyCORS(cfg config.CORS) {
+	m.cors.Store(newCORSPolicy(cfg, m.lg))
+}
+
+// CorsMiddleware must wrap the router: preflight requests match no route
+func (m *middleware) CorsMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := m.cors.Load().rule(r.URL.Path)
+		if c == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+		c.ServeHTTP(w, r, h.ServeHTTP)
+	})
 }
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/idempotency"
+	"go-clean-template/pkg/logger"
+	"net/http"
+	"strconv"
+)
+
+const maxIdempotencyKeyLen = 255
+
+type idempotencyRecorder struct {
+	w          http.ResponseWriter
+	statusCode int
+	header     http.Header
+	body       bytes.Buffer
+}
+
+func (r *idempotencyRecorder) Header() http.Header {
+	return r.w.Header()
+}
+
+func (r *idempotencyRecorder) Write(b []byte) (int, error) {
+	if r.statusCode == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(b)
+	return r.w.Write(b)
+}
+
+func (r *idempotencyRecorder) WriteHeader(statusCode int) {
+	if r.statusCode != 0 {
+		return
+	}
+	r.statusCode = statusCode
+	r.header = r.w.Header().Clone()
+	// a replay answers another request, it gets its own ID
+	r.header.Del(logger.HeaderRequestID)
+	r.w.WriteHeader(statusCode)
+}
+
+// IdempotencyMiddleware stores the first response of a mutating request with Idempotency-Key header
+// and replays it on retries. Keys are scoped by the principal, the method and the route. Failed requests
+// (5xx or panic) and rejections that the client can fix (401, 403, 429) release the key so they can be retried.
+func (m *middleware) IdempotencyMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotency.HeaderKey)
+		if !m.cfg.Idempotency.Enabled || key == "" || isSafeMethod(r.Method) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			m.errs.Render(w, r, domain.NewValidationError(domain.NewFieldError(idempotency.HeaderKey,
+				domain.CodeFieldTooLarge, domain.Params{"param": strconv.Itoa(maxIdempotencyKeyLen) + " bytes"})))
+			return
+		}
+
+		body, err := readReqBody(r)
+		if err != nil {
+			m.writeBodyError(w, r, err)
+			return
+		}
+		hash := idempotency.RequestHash(r.Method, r.URL.RequestURI(), body)
+
+		principal := ""
+		if p, ok := domain.PrincipalFromContext(r.Context()); ok {
+			principal = p.Method + ":" + p.Subject
+		}
+		storeKey := idempotency.ScopedKey(principal, r.Method, routeTemplate(r), key)
+
+		ctx := context.WithoutCancel(r.Context())
+		rec, acquired, err := m.idem.Acquire(ctx, storeKey, hash, m.cfg.Idempotency.TTL,
+			m.cfg.Idempotency.LockTimeout)
+		if err != nil {
+			m.errs.Render(w, r, err)
+			return
+		}
+		if !acquired {
+			m.replay(w, r, key, rec, hash)
+			return
+		}
+
+		rr := &idempotencyRecorder{w: w}
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := m.idem.Release(ctx, storeKey); err != nil {
+				m.lg.WithContext(ctx).Error(fmt.Errorf("idempotency release: %w", err))
+			}
+		}()
+
+		h.ServeHTTP(rr, r)
+
+		if rr.statusCode == 0 || rr.statusCode >= http.StatusInternalServerError || !storable(rr.statusCode) {
+			return
+		}
+		rec.Status, rec.Header, rec.Body = rr.statusCode, rr.header, rr.body.Bytes()
+		if err := m.idem.Complete(ctx, rec); err != nil {
+			m.lg.WithContext(ctx).Error(fmt.Errorf("idempotency complete: %w", err))
+			return
+		}
+		completed = true
+	})
+}
+
+// storable tells responses that are the outcome of the request from rejections that end once the client
+// fixes its credentials or waits
+func storable(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return false
+	}
+	return true
+}
+
+func (m *middleware) replay(w http.ResponseWriter, r *http.Request, key string, rec idempotency.Record,
+	hash string) {
+	params := domain.Params{"key": key}
+	switch {
+	case rec.RequestHash != hash:
+		m.errs.Write(w, r, http.StatusUnprocessableEntity, domain.CodeIdempotencyKeyReused, params)
+	case rec.State != idempotency.StateCompleted:
+		w.Header().Set("Retry-After", "1")
+		m.errs.Write(w, r, http.StatusConflict, domain.CodeIdempotencyInProgress, params)
+	default:
+		// headers of this request, like X-Request-ID, are kept
+		for k, v := range rec.Header {
+			if _, ok := w.Header()[k]; !ok {
+				w.Header()[k] = v
+			}
+		}
+		w.Header().Set(idempotency.HeaderReplayed, "true")
+		w.WriteHeader(rec.Status)
+		if _, err := w.Write(rec.Body); err != nil {
+			m.lg.WithContext(r.Context()).Error(fmt.Errorf("idempotency replay: %w", err))
+		}
+	}
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
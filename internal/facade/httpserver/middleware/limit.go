@@ -1,58 +1,192 @@
 package middleware
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go-clean-template/config"
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/logger"
+	"go-clean-template/pkg/ratelimit"
+	"math"
+	"net"
 	"net/http"
-	"sync"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
-const defaultLimit = 10
+const (
+	rateLimitMetrics = "rate_limit"
+	defaultRouteID   = "*"
 
-var trackingRoutes = map[string]int{ //nolint: gochecknoglobals //tracking routes
-	// TODO: move paths to config
-	"/api/v1/domain": defaultLimit,
-}
+	rateLimitKeyIP        = "ip"
+	rateLimitKeyAPIKey    = "api-key"
+	rateLimitKeyPrincipal = "principal"
+)
 
-type pendingStats struct {
-	stats map[string]int
-	sync.RWMutex
+type rateRule struct {
+	id    string
+	key   string
+	limit ratelimit.Limit
 }
 
-func newPendingStats() *pendingStats {
-	return &pendingStats{
-		stats: make(map[string]int),
-	}
+// rateRules holds the limits of config by "METHOD /route/template", def is nil if there is no default limit
+type rateRules struct {
+	routes  map[string]rateRule
+	def     *rateRule
+	proxies []netip.Prefix
 }
 
-func (p *pendingStats) Inc(route string) {
-	p.Lock()
-	defer p.Unlock()
-	p.stats[route]++
+func newRateRules(cfg config.RateLimit, lg logger.Logger) rateRules {
+	key := cfg.Key
+	if key == "" {
+		key = rateLimitKeyPrincipal
+	}
+	rules := rateRules{routes: make(map[string]rateRule)}
+	if l, ok := newLimit(cfg.Default); ok {
+		rules.def = &rateRule{defaultRouteID, key, l}
+	}
+	for _, rt := range cfg.Routes {
+		l, ok := newLimit(rt.RateLimitRule)
+		if !ok {
+			continue
+		}
+		id := strings.ToUpper(rt.Method) + " " + rt.Path
+		routeKey := key
+		if rt.Key != "" {
+			routeKey = rt.Key
+		}
+		rules.routes[id] = rateRule{id, routeKey, l}
+	}
+	for _, cidr := range cfg.TrustedProxies {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			lg.Error(fmt.Errorf("rate limit trusted proxy %s: %w", cidr, err))
+			continue
+		}
+		rules.proxies = append(rules.proxies, prefix)
+	}
+	return rules
 }
 
-func (p *pendingStats) Dec(route string) {
-	p.Lock()
-	defer p.Unlock()
-	p.stats[route]--
+func newLimit(rule config.RateLimitRule) (ratelimit.Limit, bool) {
+	if rule.Requests <= 0 || rule.Per <= 0 {
+		return ratelimit.Limit{}, false
+	}
+	l := ratelimit.Per(rule.Requests, rule.Per)
+	if rule.Burst > 0 {
+		l.Burst = rule.Burst
+	}
+	return l, true
 }
 
-func (p *pendingStats) Get(route string) int {
-	p.RLock()
-	defer p.RUnlock()
-	return p.stats[route]
+func (rs rateRules) rule(method, route string) (rateRule, bool) {
+	if rule, ok := rs.routes[method+" "+route]; ok {
+		return rule, true
+	}
+	if rs.def != nil {
+		return *rs.def, true
+	}
+	return rateRule{}, false
 }
 
-func (m *middleware) LimiterMiddleware(h http.Handler) http.Handler {
+// RateLimitMiddleware takes a token from the bucket of the client and the route. It must run after AuthMiddleware
+// to key buckets by principal. Store errors let the request pass: the limiter must not take the API down.
+func (m *middleware) RateLimitMiddleware(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		path := r.URL.Path
-		m.pendingStats.Inc(path)
-		defer m.pendingStats.Dec(path)
-		if limit, ok := trackingRoutes[path]; ok {
-			if m.pendingStats.Get(path) > limit {
-				w.WriteHeader(http.StatusTooManyRequests)
-				return
-			}
+		rule, ok := m.rates.rule(r.Method, routeTemplate(r))
+		if !m.cfg.RateLimit.Enabled || !ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		client := m.rateLimitClient(r, rule.key)
+		res, err := m.limits.Take(r.Context(), rule.id+"|"+client, rule.limit)
+		if err != nil {
+			m.lg.WithContext(r.Context()).Error(fmt.Errorf("rate limit: %w", err))
+			h.ServeHTTP(w, r)
+			return
+		}
+		m.mon.Count(rateLimitMetrics, rule.id, !res.Allowed)
+
+		header := w.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		header.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s", rule.limit.Burst, ceilSeconds(rule.limit.Window())))
+		if !res.Allowed {
+			retryAfter := ceilSeconds(res.RetryAfter)
+			header.Set("Retry-After", retryAfter)
+			m.errs.Write(w, r, http.StatusTooManyRequests, domain.CodeRateLimited,
+				domain.Params{"retry_after": retryAfter})
+			return
 		}
 
 		h.ServeHTTP(w, r)
 	})
 }
+
+// rateLimitClient identifies the bucket owner, keys are prefixed with their kind so they can't collide
+func (m *middleware) rateLimitClient(r *http.Request, key string) string {
+	p, authenticated := domain.PrincipalFromContext(r.Context())
+	switch key {
+	case rateLimitKeyPrincipal:
+		if authenticated {
+			return "principal:" + p.Actor()
+		}
+	case rateLimitKeyAPIKey:
+		if authenticated && p.Method == domain.AuthMethodAPIKey {
+			return "api-key:" + p.KeyID
+		}
+		header := m.cfg.Auth.APIKeys.Header
+		if header == "" {
+			header = defaultAPIKeyHeader
+		}
+		// keys are not stored in plain text even in memory
+		if apiKey := r.Header.Get(header); apiKey != "" {
+			sum := sha256.Sum256([]byte(apiKey))
+			return "api-key:" + hex.EncodeToString(sum[:])
+		}
+	}
+	return rateLimitKeyIP + ":" + m.clientIP(r)
+}
+
+// clientIP walks X-Forwarded-For from the right, clients can write any entry but the ones appended by
+// the proxies they passed
+func (m *middleware) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	fwd := r.Header.Values("X-Forwarded-For")
+	if !m.cfg.RateLimit.TrustForwardedFor || len(fwd) == 0 {
+		return host
+	}
+	hops := strings.Split(strings.Join(fwd, ","), ",")
+	if len(m.rates.proxies) == 0 {
+		return strings.TrimSpace(hops[len(hops)-1])
+	}
+
+	ip := host
+	for i := len(hops) - 1; i >= 0 && m.trustedProxy(ip); i-- {
+		ip = strings.TrimSpace(hops[i])
+	}
+	return ip
+}
+
+func (m *middleware) trustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(m.rates.proxies, func(p netip.Prefix) bool {
+		return p.Contains(addr.Unmap())
+	})
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
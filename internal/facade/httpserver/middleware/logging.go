@@ -2,19 +2,25 @@ package middleware
 
 import (
 	"bytes"
-	"context"
+	"encoding/json"
 	"fmt"
+	"go-clean-template/config"
+	"go-clean-template/internal/domain"
 	"io"
+	"math/rand/v2"
+	"mime"
 	"net/http"
+	"regexp"
 	"slices"
 	"strings"
-
-	"github.com/google/uuid"
+	"time"
 )
 
-type ContextKey string
-
-const contextKeyRequestID ContextKey = "requestID"
+const (
+	defaultMaxLoggedBody = 4 << 10
+	redacted             = "[REDACTED]"
+	logBodyOff           = "off"
+)
 
 var ignorePaths = []string{ //nolint:gochecknoglobals //calls from infra should not be logged
 	"/metrics",
@@ -22,10 +28,185 @@ var ignorePaths = []string{ //nolint:gochecknoglobals //calls from infra should
 	"/api/ready",
 }
 
+type logRoute struct {
+	body       bool
+	sampleRate float64
+}
+
+// logPolicy is config.HTTPLogging prepared for RequestLogger, routes are keyed by "METHOD /route/template"
+type logPolicy struct {
+	def           logRoute
+	routes        map[string]logRoute
+	headers       bool
+	maxBody       int
+	redactHeaders map[string]struct{}
+	redactFields  map[string]struct{}
+	// fieldsRe redacts fields of JSON bodies that can't be parsed, e.g. truncated ones
+	fieldsRe  *regexp.Regexp
+	skipTypes []string
+}
+
+func newLogPolicy(cfg config.HTTPLogging) *logPolicy {
+	p := &logPolicy{
+		def:           logRoute{cfg.Body != logBodyOff, sampleRate(cfg.SampleRate, 1)},
+		routes:        make(map[string]logRoute),
+		headers:       cfg.Headers,
+		maxBody:       cfg.MaxBodyBytes,
+		redactHeaders: make(map[string]struct{}),
+		redactFields:  make(map[string]struct{}),
+		skipTypes:     cfg.SkipContentTypes,
+	}
+	if p.maxBody <= 0 {
+		p.maxBody = defaultMaxLoggedBody
+	}
+	for _, rt := range cfg.Routes {
+		route := logRoute{p.def.body, sampleRate(rt.SampleRate, p.def.sampleRate)}
+		if rt.Body != "" {
+			route.body = rt.Body != logBodyOff
+		}
+		p.routes[strings.ToUpper(rt.Method)+" "+rt.Path] = route
+	}
+	for _, h := range cfg.RedactHeaders {
+		p.redactHeaders[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+	fields := make([]string, 0, len(cfg.RedactFields))
+	for _, f := range cfg.RedactFields {
+		p.redactFields[strings.ToLower(f)] = struct{}{}
+		fields = append(fields, regexp.QuoteMeta(f))
+	}
+	if len(fields) > 0 {
+		p.fieldsRe = regexp.MustCompile(`(?i)"(` + strings.Join(fields, "|") +
+			`)"\s*:\s*("(?:[^"\\]|\\.)*"?|[^,}\]\s]*)`)
+	}
+	return p
+}
+
+func sampleRate(rate, def float64) float64 {
+	switch {
+	case rate < 0:
+		return 0
+	case rate == 0:
+		return def
+	default:
+		return min(rate, 1)
+	}
+}
+
+func (p *logPolicy) route(method, route string) logRoute {
+	if rt, ok := p.routes[method+" "+route]; ok {
+		return rt
+	}
+	return p.def
+}
+
+func (p *logPolicy) skipped(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range p.skipTypes {
+		if prefix, ok := strings.CutSuffix(t, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if strings.EqualFold(mediaType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatBody renders the captured head of a body of size bytes, size is negative if unknown
+func (p *logPolicy) formatBody(contentType string, head []byte, size int) string {
+	if p.skipped(contentType) {
+		if size < 0 {
+			return fmt.Sprintf("[%s]", contentType)
+		}
+		return fmt.Sprintf("[%d bytes %s]", size, contentType)
+	}
+	truncated := len(head) > p.maxBody
+	if truncated {
+		head = head[:p.maxBody]
+	}
+	if isJSON(contentType) && len(p.redactFields) > 0 {
+		head = p.redactJSON(head, truncated)
+	}
+	switch {
+	case !truncated:
+		return string(head)
+	case size < 0:
+		return fmt.Sprintf("%s... [truncated]", head)
+	default:
+		return fmt.Sprintf("%s... [%d bytes]", head, size)
+	}
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+func (p *logPolicy) redactJSON(body []byte, truncated bool) []byte {
+	if !truncated {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err == nil {
+			if out, err := json.Marshal(p.redactValue(v)); err == nil {
+				return out
+			}
+		}
+	}
+	return p.fieldsRe.ReplaceAll(body, []byte(`"$1":"`+redacted+`"`))
+}
+
+func (p *logPolicy) redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if _, ok := p.redactFields[strings.ToLower(k)]; ok {
+				v[k] = redacted
+			} else {
+				v[k] = p.redactValue(val)
+			}
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = p.redactValue(val)
+		}
+	}
+	return v
+}
+
+func (p *logPolicy) formatHeaders(h http.Header) string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		v := strings.Join(h[k], ", ")
+		if _, ok := p.redactHeaders[k]; ok {
+			v = redacted
+		}
+		fmt.Fprintf(&sb, " %s: %s;", k, v)
+	}
+	return sb.String()
+}
+
+// ApplyLogging replaces the logging policy, it is called on config reload
+func (m *middleware) ApplyLogging(cfg config.HTTPLogging) {
+	m.logging.Store(newLogPolicy(cfg))
+}
+
+// ResponseLogger keeps the status and up to limit+1 bytes of the response, size counts all of them
 type ResponseLogger struct {
 	w          http.ResponseWriter
 	statusCode int
-	body       []string
+	body       bytes.Buffer
+	size       int
+	limit      int
 }
 
 func (r *ResponseLogger) Header() http.Header {
@@ -33,7 +214,10 @@ func (r *ResponseLogger) Header() http.Header {
 }
 
 func (r *ResponseLogger) Write(res []byte) (int, error) {
-	r.body = append(r.body, string(res))
+	if rest := r.limit + 1 - r.body.Len(); r.limit > 0 && rest > 0 {
+		r.body.Write(res[:min(rest, len(res))])
+	}
+	r.size += len(res)
 	return r.w.Write(res)
 }
 
@@ -42,52 +226,88 @@ func (r *ResponseLogger) WriteHeader(statusCode int) {
 	r.w.WriteHeader(statusCode)
 }
 
-func (r *ResponseLogger) fullResponse() string {
-	if len(r.body) == 1 {
-		return r.body[0]
+func (r *ResponseLogger) Flush() {
+	if f, ok := r.w.(http.Flusher); ok {
+		f.Flush()
 	}
-	var res string
-	for i, b := range r.body {
-		res += fmt.Sprintf("written %d: %s\n", i+1, b)
-	}
-	return res
 }
 
+func (r *ResponseLogger) Unwrap() http.ResponseWriter {
+	return r.w
+}
+
+// RequestLogger logs the request and the response once the response is written: error responses always,
+// successful ones with the sample rate of the route. Bodies are capped and redacted by the logging policy.
 func (m *middleware) RequestLogger(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		reqID := uuid.New()
-		ctx := context.WithValue(r.Context(), contextKeyRequestID, reqID.String())
-		r = r.WithContext(ctx)
-
-		body, err := readReqBody(r)
-		if err != nil {
-			m.lg.Error(reqID.String(), err)
-			http.Error(w, err.Error(), http.StatusBadRequest)
+		if slices.Contains(ignorePaths, r.RequestURI) {
+			h.ServeHTTP(w, r)
 			return
 		}
+		pol := m.logging.Load()
+		route := pol.route(r.Method, routeTemplate(r))
+		lg := m.lg.WithContext(r.Context())
 
-		rl := &ResponseLogger{w: w, body: make([]string, 0)}
-		if !slices.Contains(ignorePaths, r.RequestURI) {
-			if strings.Contains(r.RequestURI, "upload") {
-				m.lg.Info(reqID.String(), fmt.Sprintf("Request: %s %s %s %d", r.Method, r.RequestURI, r.RemoteAddr, len(body)))
-			} else {
-				m.lg.Info(reqID.String(), fmt.Sprintf("Request: %s %s %s %s", r.Method, r.RequestURI, r.RemoteAddr, string(body)))
+		var reqBody []byte
+		if route.body && !pol.skipped(r.Header.Get("Content-Type")) {
+			var err error
+			reqBody, err = peekReqBody(r, pol.maxBody+1)
+			if err != nil {
+				lg.Error(err)
+				m.errs.Write(w, r, http.StatusBadRequest, domain.CodeInvalidBody, nil)
+				return
 			}
-			defer func() {
-				if strings.Contains(r.RequestURI, "search") || strings.Contains(r.RequestURI, "list") {
-					m.lg.Info(reqID.String(), fmt.Sprintf("Response: %s %s %d %d",
-						r.Method, r.RequestURI, rl.statusCode, len([]byte(rl.fullResponse()))))
-				} else {
-					m.lg.Info(reqID.String(), fmt.Sprintf("Response: %s %s %d %s",
-						r.Method, r.RequestURI, rl.statusCode, rl.fullResponse()))
-				}
-			}()
 		}
 
+		rl := &ResponseLogger{w: w, statusCode: http.StatusOK}
+		if route.body {
+			rl.limit = pol.maxBody
+		}
+		start := time.Now()
+		completed := false
+		defer func() {
+			status := rl.statusCode
+			if !completed {
+				// the panic is answered by RecoverMiddleware
+				status = http.StatusInternalServerError
+			}
+			if status < http.StatusBadRequest && rand.Float64() >= route.sampleRate { //nolint:gosec //sampling
+				return
+			}
+			reqLine := fmt.Sprintf("Request: %s %s %s", r.Method, r.RequestURI, r.RemoteAddr)
+			resLine := fmt.Sprintf("Response: %s %s %d %v", r.Method, r.RequestURI, status, time.Since(start))
+			if pol.headers {
+				reqLine += pol.formatHeaders(r.Header)
+				resLine += pol.formatHeaders(rl.Header())
+			}
+			if route.body {
+				size := int(r.ContentLength)
+				reqLine += " " + pol.formatBody(r.Header.Get("Content-Type"), reqBody, size)
+				resLine += " " + pol.formatBody(rl.Header().Get("Content-Type"), rl.body.Bytes(), rl.size)
+			}
+			lg.Info(reqLine)
+			lg.Info(resLine)
+		}()
+
 		h.ServeHTTP(rl, r)
+		completed = true
 	})
 }
 
+// peekReqBody reads up to n bytes of the body and puts them back in front of the rest of it
+func peekReqBody(r *http.Request, n int) ([]byte, error) {
+	head, err := io.ReadAll(io.LimitReader(r.Body, int64(n)))
+	if err != nil {
+		return nil, err
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+
+	return head, nil
+}
+
 func readReqBody(r *http.Request) ([]byte, error) {
 	buf := &bytes.Buffer{}
 	if _, err := buf.ReadFrom(r.Body); err != nil {
@@ -1,25 +1,100 @@
 package middleware
 
 import (
+	"go-clean-template/config"
+	"go-clean-template/internal/domain"
+	"go-clean-template/internal/facade/httpserver/problem"
+	"go-clean-template/pkg/idempotency"
+	"go-clean-template/pkg/imcache"
 	"go-clean-template/pkg/logger"
 	"go-clean-template/pkg/monitoring"
+	"go-clean-template/pkg/openapi"
+	"go-clean-template/pkg/ratelimit"
+	"net/http"
+	"sync/atomic"
 )
 
 type middleware struct {
-	pendingStats *pendingStats
-	mon          monitoring.Monitoring
-	lg           logger.Logger
+	cfg            config.HTTP
+	authenticators []Authenticator
+	policy         domain.PolicyService
+	rates          rateRules
+	limits         ratelimit.Store
+	admission      []admissionGroup
+	routeLimits    map[string]routeLimit
+	versions       map[string]config.APIVersion
+	routeAudits    map[string]AuditState
+	responses      responseStore
+	events         domain.EventBus
+	compression    *compression
+	spec           *openapi.Document
+	// validateResponses is set in LOCAL environment, responses are buffered to be checked
+	validateResponses bool
+	cors              atomic.Pointer[corsPolicy]
+	logging           atomic.Pointer[logPolicy]
+	admin             atomic.Pointer[config.Admin]
+	idem              idempotency.Repository
+	audit             domain.AuditService
+	errs              ErrorRenderer
+	mon               monitoring.Monitoring
+	lg                logger.Logger
+}
+
+type ErrorRenderer interface {
+	Render(w http.ResponseWriter, r *http.Request, err error)
+	Write(w http.ResponseWriter, r *http.Request, status int, code domain.ErrorCode, params domain.Params)
 }
 
 type Provider interface {
+	GetAuditService() domain.AuditService
+	GetAuthService() domain.AuthService
+	GetPolicyService() domain.PolicyService
+	GetEnv() string
+	GetIdempotencyRepository() idempotency.Repository
+	GetRateLimitStore() ratelimit.Store
+	GetEventBus() domain.EventBus
 	GetMonitoring() monitoring.Monitoring
 	GetLogger() logger.Logger
 }
 
-func New(prov Provider) *middleware {
-	return &middleware{
-		newPendingStats(),
-		prov.GetMonitoring(),
-		prov.GetLogger(),
+func New(cfg config.HTTP, prov Provider) *middleware {
+	mon := prov.GetMonitoring()
+	if cfg.RateLimit.Enabled {
+		mon.Register(rateLimitMetrics)
+	}
+	if cfg.Versions.Default != "" || len(cfg.Versions.Lifecycle) > 0 {
+		mon.Register(apiVersionMetrics)
+	}
+	if cfg.Admission.Enabled {
+		mon.Register(admissionMetrics)
+		mon.RegisterGauge(admissionInFlight)
+		mon.RegisterGauge(admissionQueued)
+		mon.RegisterGauge(admissionLimit)
+	}
+	m := &middleware{
+		cfg:               cfg,
+		authenticators:    newAuthenticators(cfg.Auth, prov.GetAuthService()),
+		policy:            prov.GetPolicyService(),
+		rates:             newRateRules(cfg.RateLimit, prov.GetLogger()),
+		limits:            prov.GetRateLimitStore(),
+		admission:         newAdmissionGroups(cfg.Admission, prov.GetLogger()),
+		compression:       newCompression(cfg.Compression, prov.GetLogger()),
+		validateResponses: cfg.OpenAPI.Validate && prov.GetEnv() == envLocal,
+		routeLimits:       make(map[string]routeLimit),
+		versions:          newVersions(cfg.Versions),
+		routeAudits:       make(map[string]AuditState),
+		events:            prov.GetEventBus(),
+		idem:              prov.GetIdempotencyRepository(),
+		audit:             prov.GetAuditService(),
+		errs:              problem.New(prov),
+		mon:               mon,
+		lg:                prov.GetLogger(),
+	}
+	if cfg.Cache.Enabled && cfg.Cache.Server {
+		m.responses = imcache.New[string, cacheEntry]()
 	}
+	m.ApplyCORS(cfg.CORS)
+	m.ApplyLogging(cfg.Logging)
+	m.ApplyAdmin(config.Admin{})
+	return m
 }
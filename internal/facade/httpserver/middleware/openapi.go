@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/openapi"
+	"go-clean-template/pkg/validate"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const envLocal = "LOCAL"
+
+// UseOpenAPI sets the spec that requests are validated against, the router builds it after registering routes
+func (m *middleware) UseOpenAPI(doc *openapi.Document) {
+	m.spec = doc
+}
+
+// validateRequest checks the parameters and the JSON body of a documented operation,
+// it returns false if it has written an error
+func (m *middleware) validateRequest(w http.ResponseWriter, r *http.Request, op *openapi.Operation) bool {
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+	errs := m.spec.ValidateParams(op, func(in, name string) []string {
+		switch in {
+		case openapi.InPath:
+			if v, ok := vars[name]; ok {
+				return []string{v}
+			}
+		case openapi.InQuery:
+			return query[name]
+		case openapi.InHeader:
+			return r.Header.Values(name)
+		}
+		return nil
+	})
+
+	if op.RequestBody != nil {
+		if s := op.RequestBody.Content[openapi.ContentTypeJSON].Schema; s != nil {
+			body, err := readReqBody(r)
+			if err != nil {
+				m.writeBodyError(w, r, err)
+				return false
+			}
+			errs = append(errs, m.validateBody(body, op.RequestBody.Required, s)...)
+		}
+	}
+	if err := domain.ValidationErrorOf(errs); err != nil {
+		m.errs.Render(w, r, err)
+		return false
+	}
+	return true
+}
+
+func (m *middleware) validateBody(body []byte, required bool, s *openapi.Schema) []validate.FieldError {
+	if len(bytes.TrimSpace(body)) == 0 {
+		if required {
+			return []validate.FieldError{{Field: "body", Rule: validate.RuleRequired, Message: "is required"}}
+		}
+		return nil
+	}
+	return m.spec.ValidateJSON(s, body, "")
+}
+
+// validateResponse writes the checked response, a response that breaks the spec is replaced with
+// an internal error, so the mismatch is noticed while developing
+func (m *middleware) validateResponse(sw *bufferedWriter, r *http.Request, op *openapi.Operation) {
+	if sw.streaming {
+		return
+	}
+	if err := m.checkResponse(sw, op); err != nil {
+		m.errs.Render(sw.w, r, fmt.Errorf("openapi: %w", err))
+		return
+	}
+	if err := sw.send(); err != nil {
+		m.lg.WithContext(r.Context()).Error(fmt.Errorf("openapi: %w", err))
+	}
+}
+
+func (m *middleware) checkResponse(sw *bufferedWriter, op *openapi.Operation) error {
+	if sw.statusCode == 0 || sw.statusCode == http.StatusNoContent {
+		return nil
+	}
+	resp, ok := op.Responses[strconv.Itoa(sw.statusCode)]
+	if !ok {
+		if sw.statusCode >= http.StatusBadRequest {
+			// errors are described by the default response
+			return nil
+		}
+		return fmt.Errorf("status %d is not documented", sw.statusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(sw.Header().Get("Content-Type"))
+	if mediaType != openapi.ContentTypeJSON {
+		return nil
+	}
+	s := resp.Content[openapi.ContentTypeJSON].Schema
+	if s == nil {
+		return nil
+	}
+	if errs := m.spec.ValidateJSON(s, sw.buf.Bytes(), "response"); len(errs) > 0 {
+		msgs := make([]string, 0, len(errs))
+		for _, e := range errs {
+			msgs = append(msgs, e.Error())
+		}
+		return fmt.Errorf("response %d doesn't match the spec: %s", sw.statusCode, strings.Join(msgs, "; "))
+	}
+	return nil
+}
@@ -9,7 +9,7 @@ import (
 )
 
 func (m *middleware) RecoverMiddleware(h http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		var err error
 		defer func() {
 			r := recover()
@@ -23,10 +23,9 @@ func (m *middleware) RecoverMiddleware(h http.Handler) http.Handler {
 				default:
 					err = errors.New("unknown panic")
 				}
-				m.lg.Error(err.Error())
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				m.errs.Render(w, req, err)
 			}
 		}()
-		h.ServeHTTP(w, r)
+		h.ServeHTTP(w, req)
 	})
 }
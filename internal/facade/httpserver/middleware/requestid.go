@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"go-clean-template/pkg/logger"
+	"net/http"
+)
+
+// RequestIDMiddleware takes the request ID from X-Request-ID or generates one, puts it into the context
+// and echoes it in the response. It must be the first middleware so every log line of the request has the ID.
+func (m *middleware) RequestIDMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqID := r.Header.Get(logger.HeaderRequestID)
+		if !logger.ValidRequestID(reqID) {
+			reqID = logger.NewRequestID()
+		}
+
+		w.Header().Set(logger.HeaderRequestID, reqID)
+		h.ServeHTTP(w, r.WithContext(logger.ContextWithRequestID(r.Context(), reqID)))
+	})
+}
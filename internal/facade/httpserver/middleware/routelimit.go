@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go-clean-template/internal/domain"
+	"maps"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+type routeLimit struct {
+	maxBodySize     int64
+	timeout         time.Duration
+	bodyReadTimeout time.Duration
+}
+
+// LimitRoute sets the limits of a route, zero takes the default of config and negative turns it off.
+// Routes are registered before the server starts, so routeLimits is not guarded.
+func (m *middleware) LimitRoute(method, path string, maxBodySize int64, timeout, bodyReadTimeout time.Duration) {
+	l := routeLimit{
+		maxBodySize:     orDefault(maxBodySize, m.cfg.Limits.MaxBodySize),
+		timeout:         orDefault(timeout, m.cfg.Limits.Timeout),
+		bodyReadTimeout: orDefault(bodyReadTimeout, m.cfg.Limits.BodyReadTimeout),
+	}
+	m.routeLimits[strings.ToUpper(method)+" "+path] = l
+}
+
+func orDefault[T int64 | time.Duration](v, def T) T {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+// LimitMiddleware applies the limits of the route: the body size, the deadline of the handler
+// and the read deadline of the body
+func (m *middleware) LimitMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l, ok := m.routeLimits[r.Method+" "+routeTemplate(r)]
+		if !ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		if l.maxBodySize > 0 {
+			if r.ContentLength > l.maxBodySize {
+				m.errs.Render(w, r, &http.MaxBytesError{Limit: l.maxBodySize})
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, l.maxBodySize)
+		}
+		if l.bodyReadTimeout > 0 {
+			err := http.NewResponseController(w).SetReadDeadline(time.Now().Add(l.bodyReadTimeout))
+			if err != nil && !errors.Is(err, http.ErrNotSupported) {
+				m.lg.WithContext(r.Context()).Error(err)
+			}
+		}
+		if l.timeout > 0 {
+			m.serveWithTimeout(w, r, h, l.timeout)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// timeoutWriter buffers the response until the handler returns, writes after the deadline fail
+type timeoutWriter struct {
+	mu          sync.Mutex
+	h           http.Header
+	buf         bytes.Buffer
+	code        int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeader(http.StatusOK)
+	}
+	return tw.buf.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeader(code)
+}
+
+func (tw *timeoutWriter) writeHeader(code int) {
+	tw.wroteHeader = true
+	tw.code = code
+}
+
+// serveWithTimeout runs the handler like http.TimeoutHandler: handlers that ignore the deadline of the
+// context still get a 503 problem in time. The response is buffered, streaming routes must turn the
+// timeout off. A panic of the handler is passed on to RecoverMiddleware.
+func (m *middleware) serveWithTimeout(w http.ResponseWriter, r *http.Request, h http.Handler,
+	timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	r = r.WithContext(ctx)
+
+	tw := &timeoutWriter{h: make(http.Header)}
+	done := make(chan struct{})
+	panics := make(chan any, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				// the stack of the handler is lost when the panic is raised again
+				if p != http.ErrAbortHandler {
+					p = fmt.Sprintf("%v, handler stacktrace: %s", p, debug.Stack())
+				}
+				panics <- p
+			}
+		}()
+		h.ServeHTTP(tw, r)
+		close(done)
+	}()
+
+	select {
+	case p := <-panics:
+		panic(p)
+	case <-done:
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		maps.Copy(w.Header(), tw.h)
+		if !tw.wroteHeader {
+			tw.code = http.StatusOK
+		}
+		w.WriteHeader(tw.code)
+		if _, err := w.Write(tw.buf.Bytes()); err != nil {
+			m.lg.WithContext(ctx).Debug(err)
+		}
+	case <-ctx.Done():
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		tw.timedOut = true
+		// a client that went away gets no answer
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			m.errs.Write(w, r, http.StatusServiceUnavailable, domain.CodeHandlerTimeout, nil)
+		}
+	}
+}
+
+// writeBodyError answers a body that can't be read, the limits of the route have their own statuses
+func (m *middleware) writeBodyError(w http.ResponseWriter, r *http.Request, err error) {
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) || errors.Is(err, os.ErrDeadlineExceeded) {
+		m.errs.Render(w, r, err)
+		return
+	}
+	m.errs.Write(w, r, http.StatusBadRequest, domain.CodeInvalidBody, nil)
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"go-clean-template/internal/domain"
+	"net/http"
+)
+
+// ClientCertMiddleware puts the TLS client certificate of the request into the context
+func (m *middleware) ClientCertMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		leaf := r.TLS.PeerCertificates[0]
+		cert := domain.ClientCert{
+			Subject:      leaf.Subject.String(),
+			CommonName:   leaf.Subject.CommonName,
+			Issuer:       leaf.Issuer.String(),
+			SerialNumber: leaf.SerialNumber.String(),
+			DNSNames:     leaf.DNSNames,
+			Verified:     len(r.TLS.VerifiedChains) > 0,
+		}
+		h.ServeHTTP(w, r.WithContext(domain.ContextWithClientCert(r.Context(), cert)))
+	})
+}
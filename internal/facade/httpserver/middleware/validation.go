@@ -1,16 +1,42 @@
 package middleware
 
 import (
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/openapi"
 	"net/http"
 	"unicode/utf8"
 )
 
+// ValidationMiddleware rejects paths that are not UTF-8. With openapi validation it checks requests
+// against the spec, in LOCAL environment responses are checked too.
 func (m *middleware) ValidationMiddleware(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if !utf8.ValidString(r.URL.Path) {
-			w.WriteHeader(http.StatusBadRequest)
+			m.errs.Write(w, r, http.StatusBadRequest, domain.CodeInvalidPath, nil)
 			return
 		}
-		h.ServeHTTP(w, r)
+		op := m.operation(r)
+		if op == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if !m.validateRequest(w, r, op) {
+			return
+		}
+		if !m.validateResponses {
+			h.ServeHTTP(w, r)
+			return
+		}
+		sw := &bufferedWriter{w: w}
+		h.ServeHTTP(sw, r)
+		m.validateResponse(sw, r, op)
 	})
 }
+
+// operation returns the documented operation of the request, nil if it is not validated
+func (m *middleware) operation(r *http.Request) *openapi.Operation {
+	if !m.cfg.OpenAPI.Validate || m.spec == nil {
+		return nil
+	}
+	return m.spec.Operation(r.Method, routeTemplate(r))
+}
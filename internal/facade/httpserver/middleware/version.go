@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"go-clean-template/config"
+	"go-clean-template/internal/domain"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const apiVersionMetrics = "api_version"
+
+// apiVersion returns the version segment of /api/{version}/... paths, "" for other paths
+func apiVersion(path string) string {
+	rest, ok := strings.CutPrefix(path, "/api/")
+	if !ok {
+		return ""
+	}
+	v, _, _ := strings.Cut(rest, "/")
+	if len(v) < 2 || v[0] != 'v' {
+		return ""
+	}
+	if _, err := strconv.Atoi(v[1:]); err != nil {
+		return ""
+	}
+	return v
+}
+
+// VersionMiddleware counts requests per API version and signals the retirement of deprecated versions,
+// a version past its sunset answers 410 Gone
+func (m *middleware) VersionMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version := apiVersion(routeTemplate(r))
+		if version == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		m.mon.Count(apiVersionMetrics, version, false)
+
+		lc, ok := m.versions[version]
+		if !ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if !lc.Deprecated.IsZero() {
+			// RFC 9745 structured date
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(lc.Deprecated.Unix(), 10))
+		}
+		if !lc.Sunset.IsZero() {
+			w.Header().Set("Sunset", lc.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if lc.Link != "" {
+			w.Header().Add("Link", "<"+lc.Link+`>; rel="deprecation"`)
+		}
+		if !lc.Sunset.IsZero() && time.Now().After(lc.Sunset) {
+			m.errs.Write(w, r, http.StatusGone, domain.CodeVersionRetired,
+				domain.Params{"version": version, "sunset": lc.Sunset.Format(time.DateOnly)})
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func newVersions(cfg config.Versions) map[string]config.APIVersion {
+	versions := make(map[string]config.APIVersion, len(cfg.Lifecycle))
+	for _, v := range cfg.Lifecycle {
+		versions[v.Name] = v
+	}
+	return versions
+}
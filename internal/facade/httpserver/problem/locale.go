@@ -0,0 +1,37 @@
+package problem
+
+import (
+	"go-clean-template/internal/domain"
+	"net/http"
+
+	"golang.org/x/text/language"
+)
+
+var matcher = newMatcher() //nolint:gochecknoglobals //built once from the catalog locales
+
+func newMatcher() language.Matcher {
+	locales := domain.Locales()
+	tags := make([]language.Tag, 0, len(locales))
+	for _, l := range locales {
+		tags = append(tags, language.Make(string(l)))
+	}
+	return language.NewMatcher(tags)
+}
+
+// Locale picks the best catalog locale for the Accept-Language header of r
+func Locale(r *http.Request) domain.Locale {
+	accept := r.Header.Get("Accept-Language")
+	if accept == "" {
+		return domain.DefaultLocale
+	}
+	tags, _, err := language.ParseAcceptLanguage(accept)
+	if err != nil || len(tags) == 0 {
+		return domain.DefaultLocale
+	}
+
+	_, idx, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return domain.DefaultLocale
+	}
+	return domain.Locales()[idx]
+}
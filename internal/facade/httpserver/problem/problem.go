@@ -0,0 +1,177 @@
+// Package problem renders errors as RFC 7807 application/problem+json responses.
+// Domain errors are mapped to statuses in one table, errors missing from it are internal errors
+// and their text is shown only in LOCAL environment. Details are localized from the error code
+// catalog by Accept-Language.
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/logger"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+const (
+	ContentType = "application/problem+json"
+	envLocal    = "LOCAL"
+	typePrefix  = "/problems/"
+)
+
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Code      domain.ErrorCode    `json:"code"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+	Errors    []domain.FieldError `json:"errors,omitempty"`
+}
+
+type mapping struct {
+	match  func(err error) (domain.CodedError, bool)
+	status int
+	slug   string
+	title  string
+}
+
+func as[T domain.CodedError](status int, slug, title string) mapping {
+	return mapping{
+		func(err error) (domain.CodedError, bool) {
+			var target T
+			if errors.As(err, &target) {
+				return target, true
+			}
+			return nil, false
+		},
+		status,
+		slug,
+		title,
+	}
+}
+
+// mappings is the single place to map domain errors to HTTP, the first match wins
+var mappings = []mapping{ //nolint:gochecknoglobals //error mapping table
+	as[domain.ValidationError](http.StatusBadRequest, "validation-error", "Validation failed"),
+	as[domain.UnauthorizedError](http.StatusUnauthorized, "unauthorized", "Unauthorized"),
+	as[domain.ForbiddenError](http.StatusForbidden, "forbidden", "Forbidden"),
+	as[domain.NotFoundError](http.StatusNotFound, "not-found", "Not found"),
+	as[domain.AlreadyProcessedError](http.StatusConflict, "already-processed", "Already processed"),
+	as[domain.UnavailableError](http.StatusServiceUnavailable, "unavailable", "Service unavailable"),
+}
+
+type renderer struct {
+	env string
+	lg  logger.Logger
+}
+
+type Provider interface {
+	GetEnv() string
+	GetLogger() logger.Logger
+}
+
+func New(prov Provider) *renderer {
+	return &renderer{
+		prov.GetEnv(),
+		prov.GetLogger(),
+	}
+}
+
+// Render writes err as a problem, errors without a mapping are logged and written as 500
+func (p *renderer) Render(w http.ResponseWriter, r *http.Request, err error) {
+	locale := Locale(r)
+
+	for _, m := range mappings {
+		coded, ok := m.match(err)
+		if !ok {
+			continue
+		}
+		code := coded.ErrorCode()
+		pr := p.newProblem(r, m.status, m.slug, m.title, code, code.Message(locale, coded.ErrorParams()))
+
+		var validation domain.ValidationError
+		if errors.As(coded, &validation) {
+			pr.Errors = localizeFields(validation.Fields, locale)
+		}
+		p.write(w, r, pr)
+		return
+	}
+
+	if status, code, params, ok := limitError(err); ok {
+		p.write(w, r, p.newProblem(r, status, "", http.StatusText(status), code, code.Message(locale, params)))
+		return
+	}
+
+	p.lg.WithContext(r.Context()).Error(fmt.Errorf("%s %s: %w", r.Method, r.URL.Path, err))
+
+	pr := p.newProblem(r, http.StatusInternalServerError, "", http.StatusText(http.StatusInternalServerError),
+		domain.CodeInternal, domain.CodeInternal.Message(locale, nil))
+	if p.env == envLocal {
+		pr.Detail = err.Error()
+	}
+	p.write(w, r, pr)
+}
+
+// limitError maps errors of the body and deadline limits of routes, they don't come from the domain
+func limitError(err error) (int, domain.ErrorCode, domain.Params, bool) {
+	var maxBytes *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytes):
+		return http.StatusRequestEntityTooLarge, domain.CodeBodyTooLarge,
+			domain.Params{"limit": strconv.FormatInt(maxBytes.Limit, 10)}, true
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return http.StatusRequestTimeout, domain.CodeBodyReadTimeout, nil, true
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable, domain.CodeHandlerTimeout, nil, true
+	}
+	return 0, "", nil, false
+}
+
+// Write writes a problem that is not caused by a domain error
+func (p *renderer) Write(w http.ResponseWriter, r *http.Request, status int, code domain.ErrorCode,
+	params domain.Params) {
+	p.write(w, r, p.newProblem(r, status, "", http.StatusText(status), code, code.Message(Locale(r), params)))
+}
+
+func (p *renderer) newProblem(r *http.Request, status int, slug, title string, code domain.ErrorCode,
+	detail string) Problem {
+	typ := "about:blank"
+	if slug != "" {
+		typ = typePrefix + slug
+	}
+	return Problem{
+		Type:      typ,
+		Title:     title,
+		Status:    status,
+		Code:      code,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: logger.RequestIDFromContext(r.Context()),
+	}
+}
+
+func (p *renderer) write(w http.ResponseWriter, r *http.Request, pr Problem) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(pr.Status)
+	err := json.NewEncoder(w).Encode(&pr)
+	if err != nil {
+		p.lg.WithContext(r.Context()).Error("problem encoding:", err)
+	}
+}
+
+func localizeFields(fields []domain.FieldError, locale domain.Locale) []domain.FieldError {
+	res := make([]domain.FieldError, 0, len(fields))
+	for _, f := range fields {
+		if f.Code != "" && f.Params != nil {
+			f.Message = f.Code.Message(locale, f.Params)
+		}
+		res = append(res, f)
+	}
+	return res
+}
@@ -0,0 +1,95 @@
+package router
+
+import (
+	"go-clean-template/config"
+	"go-clean-template/internal/facade/httpserver/handler"
+	"go-clean-template/internal/facade/httpserver/middleware"
+	"go-clean-template/internal/facade/httpserver/sse"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/gorilla/mux"
+)
+
+// NewAdmin builds the router of the admin listener: pprof, metrics, probes, config and admin actions
+func NewAdmin(prov Provider) *router {
+	root := mux.NewRouter()
+	// auth, idempotency and the other public settings are not applied to admin routes, logging and openapi are
+	cfg := prov.GetConfig().HTTP
+	mw := middleware.New(config.HTTP{Logging: cfg.Logging, OpenAPI: cfg.OpenAPI}, prov)
+	mw.ApplyAdmin(prov.GetConfig().Admin)
+	prov.OnConfigReload(func(cfg *config.Config) {
+		mw.ApplyLogging(cfg.HTTP.Logging)
+		mw.ApplyAdmin(cfg.Admin)
+	})
+	rs := NewRoutes(root, mw, mw, mw, mw)
+	streams := sse.New("admin_streams", cfg.Streams, prov.GetMonitoring(), prov.GetLogger())
+
+	adminPrefix := versionPrefix(apiV1) + "/admin"
+	RegisterBackfillHandlers(prov, rs, adminPrefix)
+	RegisterJobHandlers(prov, rs, versionPrefix(apiV1))
+	RegisterJobStream(prov, rs, streams, versionPrefix(apiV1))
+	RegisterAuditHandlers(prov, rs, adminPrefix)
+	RegisterConfigHandlers(prov, rs, adminPrefix)
+	RegisterPolicyHandlers(prov, rs, adminPrefix)
+
+	r := router{
+		root,
+		rs,
+		mw,
+		streams,
+		prov,
+	}
+
+	r.initPprofHandlers()
+	r.initProbesHandlers()
+	r.initOpenAPI(prov.GetConfig().AppName+" admin", cfg.OpenAPI, config.Auth{})
+	r.initAdminMiddlewares()
+	r.initErrorHandlers()
+
+	return &r
+}
+
+func RegisterConfigHandlers(prov Provider, rs *Routes, prefix string) {
+	adminHandler := handler.NewAdminHandler(prov)
+	rs.Handle(http.MethodGet, prefix+"/config", adminHandler.GetConfig, WithSummary("Running config"),
+		WithResponse(http.StatusOK, map[string]any{}))
+	rs.Handle(http.MethodPost, prefix+"/config/reload", adminHandler.ReloadConfig, WithSummary("Reload config"),
+		WithResponse(http.StatusOK, map[string]any{}), WithAuditState(adminHandler.ConfigState))
+}
+
+func (r *router) initPprofHandlers() {
+	debugPrefix := "/debug/pprof"
+	r.root.HandleFunc(debugPrefix+"/", pprof.Index)
+	r.root.HandleFunc(debugPrefix+"/cmdline", pprof.Cmdline)
+	r.root.HandleFunc(debugPrefix+"/symbol", pprof.Symbol)
+	r.root.HandleFunc(debugPrefix+"/trace", pprof.Trace)
+
+	profilePrefix := "/profile"
+	r.root.HandleFunc(profilePrefix+"", pprof.Profile)
+	r.root.Handle(profilePrefix+"/goroutine", pprof.Handler("goroutine"))
+	r.root.Handle(profilePrefix+"/threadcreate", pprof.Handler("threadcreate"))
+	r.root.Handle(profilePrefix+"/heap", pprof.Handler("heap"))
+	r.root.Handle(profilePrefix+"/block", pprof.Handler("block"))
+	r.root.Handle(profilePrefix+"/mutex", pprof.Handler("mutex"))
+}
+
+func (r *router) initProbesHandlers() {
+	h := handler.New(r.prov)
+
+	apiPrefix := "/api"
+	r.root.HandleFunc(apiPrefix+"/version", h.GetVersion)
+	r.root.HandleFunc(apiPrefix+"/live", h.GetNoContent)
+	r.root.HandleFunc(apiPrefix+"/ready", h.GetNoContent)
+
+	r.root.Handle("/metrics", r.prov.GetMonitoring().GetMetricsHandler())
+}
+
+func (r *router) initAdminMiddlewares() {
+	r.root.Use(r.mw.RecoverMiddleware)
+	r.root.Use(r.mw.ClientCertMiddleware)
+	r.root.Use(r.mw.AdminActorMiddleware)
+	r.root.Use(r.mw.RequestLogger)
+	r.root.Use(r.mw.LimitMiddleware)
+	r.root.Use(r.mw.AuditMiddleware)
+}
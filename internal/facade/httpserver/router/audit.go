@@ -0,0 +1,14 @@
+package router
+
+import (
+	"go-clean-template/internal/domain"
+	"go-clean-template/internal/facade/httpserver/handler"
+	"go-clean-template/pkg/listquery"
+	"net/http"
+)
+
+func RegisterAuditHandlers(prov Provider, rs *Routes, prefix string) {
+	auditHandler := handler.NewAuditHandler(prov)
+	rs.Handle(http.MethodGet, prefix+"/audit", auditHandler.List, WithSummary("Search the audit log"),
+		WithList(&domain.AuditList), WithResponse(http.StatusOK, listquery.Result[domain.AuditEntry]{}))
+}
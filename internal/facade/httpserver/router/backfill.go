@@ -0,0 +1,21 @@
+package router
+
+import (
+	"go-clean-template/internal/domain"
+	"go-clean-template/internal/facade/httpserver/handler"
+	"net/http"
+)
+
+func RegisterBackfillHandlers(prov Provider, rs *Routes, prefix string) {
+	backfillHandler := handler.NewBackfillHandler(prov)
+	rs.Handle(http.MethodPost, prefix+"/backfill", backfillHandler.Start, WithSummary("Start a backfill"),
+		WithRequest(handler.BackfillRequest{}), WithResponse(http.StatusAccepted, handler.BackfillResponse{}))
+	rs.Handle(http.MethodGet, prefix+"/backfill", backfillHandler.List, WithSummary("Recent backfills"),
+		WithRequest(handler.BackfillListRequest{}), WithResponse(http.StatusOK, []domain.BackfillRun{}))
+	rs.Handle(http.MethodGet, prefix+"/backfill/{id}", backfillHandler.Get, WithSummary("Backfill with its days"),
+		WithRequest(handler.BackfillRunRequest{}), WithResponse(http.StatusOK, handler.BackfillResponse{}),
+		WithCacheControl("no-cache"))
+	rs.Handle(http.MethodPost, prefix+"/backfill/{id}/resume", backfillHandler.Resume,
+		WithSummary("Resume a backfill"), WithRequest(handler.BackfillRunRequest{}),
+		WithResponse(http.StatusAccepted, handler.BackfillResponse{}), WithAuditState(backfillHandler.State))
+}
@@ -0,0 +1,14 @@
+package router
+
+import (
+	"go-clean-template/internal/facade/httpserver/handler"
+	"net/http"
+	"time"
+)
+
+func RegisterErrorCatalogHandlers(prov Provider, rs *Routes, prefix string) {
+	h := handler.New(prov)
+	rs.Handle(http.MethodGet, prefix+"/errors", h.GetErrorCatalog, Public(), WithSummary("Catalog of error codes"),
+		WithCacheControl("public, max-age=3600"), WithServerCache(time.Hour),
+		WithResponse(http.StatusOK, map[string]any{}))
+}
@@ -0,0 +1,33 @@
+package router
+
+import (
+	"go-clean-template/internal/domain"
+	"go-clean-template/internal/facade/httpserver/handler"
+	"go-clean-template/pkg/listquery"
+	"net/http"
+)
+
+func RegisterJobHandlers(prov Provider, rs *Routes, prefix string) {
+	jobHandler := handler.NewJobHandler(prov)
+	rs.Handle(http.MethodGet, prefix+"/jobs", jobHandler.List, WithSummary("Scheduled jobs"),
+		WithResponse(http.StatusOK, []domain.Job{}))
+	rs.Handle(http.MethodGet, prefix+"/jobs/{name}/runs", jobHandler.Runs, WithSummary("Recent runs of a job"),
+		WithRequest(handler.JobRunsRequest{}), WithList(&domain.JobRunList),
+		WithResponse(http.StatusOK, listquery.Result[domain.JobRun]{}))
+	rs.Handle(http.MethodPost, prefix+"/jobs/{name}/runs", jobHandler.Trigger, WithSummary("Trigger a job"),
+		WithRequest(handler.TriggerJobRequest{}), WithResponse(http.StatusAccepted, domain.JobRun{}),
+		WithAuditState(jobHandler.State))
+}
+
+// RegisterJobStream streams job-run events of all instances to the clients of streams
+func RegisterJobStream(prov Provider, rs *Routes, streams handler.Broker, prefix string, opts ...RouteOption) {
+	streamHandler := handler.NewStreamHandler(prov, streams)
+	if relay := prov.GetEventRelay(); relay != nil {
+		streamHandler.Forward(relay, handler.StreamJobs, domain.EventJobRunStarted, domain.EventJobRunFinished)
+	}
+	opts = append([]RouteOption{
+		WithSummary("Server-Sent Events of started and finished job runs, resumed with Last-Event-ID"),
+		WithTimeout(-1), WithResponse(http.StatusOK, nil),
+	}, opts...)
+	rs.Handle(http.MethodGet, prefix+"/jobs/events", streamHandler.Jobs, opts...)
+}
@@ -0,0 +1,240 @@
+package router
+
+import (
+	"embed"
+	"encoding/json"
+	"go-clean-template/config"
+	"go-clean-template/internal/facade/httpserver/problem"
+	"go-clean-template/pkg/listquery"
+	"go-clean-template/pkg/openapi"
+	"io/fs"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	specPath   = "/openapi.json"
+	docsPath   = "/docs"
+	assetsPath = docsPath + "/swagger-ui/"
+
+	securityBearer = "bearerAuth"
+	securityAPIKey = "apiKey"
+	defaultKeyName = "X-API-Key"
+)
+
+//go:embed swagger.html
+var swaggerPage []byte //nolint:gochecknoglobals //embedded page
+
+//go:embed swagger-ui
+var swaggerUI embed.FS //nolint:gochecknoglobals //embedded assets
+
+//nolint:gochecknoglobals //mux path variables with an optional pattern
+var pathVar = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?}`)
+
+// initOpenAPI builds the spec of the registered routes, the middleware validates against it
+// even if serving is turned off
+func (r *router) initOpenAPI(title string, cfg config.OpenAPI, auth config.Auth) {
+	doc := buildSpec(title, r.prov.GetAppVersion(), r.routes.Routes(), auth)
+	r.mw.UseOpenAPI(doc)
+	if !cfg.Enabled {
+		return
+	}
+
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		r.prov.GetLogger().Error("openapi: marshal spec", err)
+		return
+	}
+	r.root.HandleFunc(specPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", openapi.ContentTypeJSON)
+		_, _ = w.Write(spec)
+	}).Methods(http.MethodGet)
+	// the page loads no third-party scripts, without the vendored assets there is no page
+	if _, err := fs.Stat(swaggerUI, "swagger-ui/swagger-ui-bundle.js"); err != nil {
+		r.prov.GetLogger().Warning("openapi: swagger-ui assets are not vendored, run make swagger-ui to serve " +
+			docsPath)
+		return
+	}
+	r.root.HandleFunc(docsPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(swaggerPage)
+	}).Methods(http.MethodGet)
+	r.root.PathPrefix(assetsPath).Handler(http.StripPrefix(docsPath, http.FileServerFS(swaggerUI))).
+		Methods(http.MethodGet)
+}
+
+func buildSpec(title, version string, routes []Route, auth config.Auth) *openapi.Document {
+	doc := openapi.New(title, version)
+	problemSchema := doc.Schema(reflect.TypeOf(problem.Problem{}))
+
+	var security []map[string][]string
+	if auth.Enabled {
+		doc.Components.SecuritySchemes = make(map[string]openapi.SecurityScheme)
+		if auth.JWT.Enabled {
+			doc.Components.SecuritySchemes[securityBearer] = openapi.SecurityScheme{
+				Type: "http", Scheme: "bearer", BearerFormat: "JWT"}
+			security = append(security, map[string][]string{securityBearer: {}})
+		}
+		if auth.APIKeys.Enabled {
+			name := auth.APIKeys.Header
+			if name == "" {
+				name = defaultKeyName
+			}
+			doc.Components.SecuritySchemes[securityAPIKey] = openapi.SecurityScheme{
+				Type: "apiKey", In: openapi.InHeader, Name: name}
+			security = append(security, map[string][]string{securityAPIKey: {}})
+		}
+	}
+
+	// operation ids must be unique, routes that still collide get a number
+	ids := make(map[string]int)
+	for _, route := range routes {
+		path := pathVar.ReplaceAllString(route.Path, "{$1}")
+		id := operationID(route.Method, path)
+		if ids[id]++; ids[id] > 1 {
+			id += strconv.Itoa(ids[id])
+		}
+		op := &openapi.Operation{
+			OperationID: id,
+			Summary:     route.Summary,
+			Tags:        []string{tag(path)},
+			Responses:   make(map[string]openapi.Response),
+		}
+
+		if route.Request != nil {
+			op.Parameters = doc.Parameters(route.Request)
+			if route.Method == http.MethodPost || route.Method == http.MethodPut || route.Method == http.MethodPatch {
+				if body := doc.Body(route.Request); body != nil {
+					op.RequestBody = &openapi.RequestBody{
+						Required: len(body.Required) > 0,
+						Content:  map[string]openapi.MediaType{openapi.ContentTypeJSON: {Schema: body}},
+					}
+				}
+			}
+		}
+		if route.List != nil {
+			op.Parameters = append(op.Parameters, listParameters(route.List)...)
+		}
+		// variables of the template that the DTO doesn't bind are still required by the router
+		for _, m := range pathVar.FindAllStringSubmatch(route.Path, -1) {
+			if !slices.ContainsFunc(op.Parameters, func(p openapi.Parameter) bool {
+				return p.In == openapi.InPath && p.Name == m[1]
+			}) {
+				op.Parameters = append(op.Parameters, openapi.Parameter{Name: m[1], In: openapi.InPath, Required: true,
+					Schema: &openapi.Schema{Type: "string"}})
+			}
+		}
+
+		for status, t := range route.Responses {
+			resp := openapi.Response{Description: http.StatusText(status)}
+			if t != nil {
+				resp.Content = map[string]openapi.MediaType{openapi.ContentTypeJSON: {Schema: doc.Schema(t)}}
+			}
+			op.Responses[strconv.Itoa(status)] = resp
+		}
+		if len(op.Responses) == 0 {
+			op.Responses[strconv.Itoa(http.StatusOK)] = openapi.Response{Description: http.StatusText(http.StatusOK)}
+		}
+		op.Responses["default"] = openapi.Response{
+			Description: "Error",
+			Content:     map[string]openapi.MediaType{problem.ContentType: {Schema: problemSchema}},
+		}
+
+		if !route.Public && len(security) > 0 {
+			op.Security = security
+			if len(route.Scopes) > 0 {
+				op.Description = "Requires scopes: " + strings.Join(route.Scopes, ", ")
+			}
+		}
+
+		doc.AddOperation(route.Method, path, op)
+	}
+	return doc
+}
+
+// operationID is the camel case of the method and the path, GET /api/v1/jobs/{name}/runs is
+// getApiV1JobsByNameRuns
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, seg := range strings.Split(path, "/") {
+		if seg == "" {
+			continue
+		}
+		if name, ok := strings.CutPrefix(seg, "{"); ok {
+			seg = "by-" + strings.TrimSuffix(name, "}")
+		}
+		for _, part := range strings.FieldsFunc(seg, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// tag groups operations by the first segment after the api prefix
+func tag(path string) string {
+	for _, seg := range strings.Split(path, "/") {
+		switch {
+		case seg == "", seg == "api", seg == "admin", strings.HasPrefix(seg, "{"):
+		case len(seg) > 1 && seg[0] == 'v' && strings.Trim(seg[1:], "0123456789") == "":
+		default:
+			return seg
+		}
+	}
+	return "default"
+}
+
+// listParameters documents the list parameters of spec, filters with operators are described with the field
+func listParameters(spec *listquery.Spec) []openapi.Parameter {
+	maxLimit := float64(spec.MaxLimit)
+	minLimit := 1.0
+	limit := &openapi.Schema{Type: "integer", Format: "int64", Minimum: &minLimit}
+	if spec.MaxLimit > 0 {
+		limit.Maximum = &maxLimit
+	}
+	var sortable []string
+	params := []openapi.Parameter{
+		{Name: listquery.ParamLimit, In: openapi.InQuery, Schema: limit},
+		{Name: listquery.ParamCursor, In: openapi.InQuery, Schema: &openapi.Schema{Type: "string",
+			Description: "next_cursor of the previous page"}},
+	}
+
+	names := make([]string, 0, len(spec.Fields))
+	for name := range spec.Fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		f := spec.Fields[name]
+		if f.Sort {
+			sortable = append(sortable, name)
+		}
+		if !f.Filter {
+			continue
+		}
+		s := &openapi.Schema{Type: "string", Description: "also " + name + "[op] with op one of " +
+			strings.Join(listquery.Operators(f.Type), ", ")}
+		switch f.Type {
+		case listquery.Int:
+			s.Type, s.Format = "integer", "int64"
+		case listquery.Time:
+			s.Description = "date-time in RFC 3339 or a date, " + s.Description
+		case listquery.UUID:
+			s.Format = "uuid"
+		case listquery.String:
+			for _, v := range f.Values {
+				s.Enum = append(s.Enum, v)
+			}
+		}
+		params = append(params, openapi.Parameter{Name: name, In: openapi.InQuery, Schema: s})
+	}
+
+	params = append(params, openapi.Parameter{Name: listquery.ParamSort, In: openapi.InQuery,
+		Schema: &openapi.Schema{Type: "string", Description: "comma separated fields of " +
+			strings.Join(sortable, ", ") + ", prefixed with - for descending order, default " + spec.Sort}})
+	return params
+}
@@ -0,0 +1,16 @@
+package router
+
+import (
+	"go-clean-template/internal/domain"
+	"go-clean-template/internal/facade/httpserver/handler"
+	"net/http"
+)
+
+func RegisterPolicyHandlers(prov Provider, rs *Routes, prefix string) {
+	policyHandler := handler.NewPolicyHandler(prov)
+	rs.Handle(http.MethodGet, prefix+"/policy", policyHandler.Get, WithSummary("Loaded authorization policy"),
+		WithResponse(http.StatusOK, domain.Policy{}), WithCacheControl("no-cache"))
+	rs.Handle(http.MethodGet, prefix+"/policy/explain", policyHandler.Explain,
+		WithSummary("Explain a decision of the policy"), WithRequest(domain.ExplainRequest{}),
+		WithResponse(http.StatusOK, domain.Decision{}))
+}
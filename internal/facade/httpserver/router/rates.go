@@ -1,12 +1,16 @@
 package router
 
 import (
+	"go-clean-template/internal/domain"
 	"go-clean-template/internal/facade/httpserver/handler"
-
-	"github.com/gorilla/mux"
+	"net/http"
+	"time"
 )
 
-func RegisterDomainHandlers(prov Provider, root *mux.Router, prefix string) {
+func RegisterDomainHandlers(prov Provider, rs *Routes, prefix string) {
 	domainHandler := handler.NewDomainHandler(prov)
-	root.HandleFunc("GET "+prefix+"/data", domainHandler.GetObjects)
+	rs.Handle(http.MethodGet, prefix+"/data", domainHandler.GetObjects, WithScopes(domain.ScopeDataRead),
+		WithSummary("Objects for a date range"), WithRequest(domain.ServiceRequest{}),
+		WithResponse(http.StatusOK, handler.ObjectsResponse{}),
+		WithCacheControl("no-cache"), WithServerCache(time.Minute, domain.EventPersistCompleted))
 }
@@ -1,83 +1,154 @@
 package router
 
 import (
+	"context"
+	"go-clean-template/config"
 	"go-clean-template/internal/domain"
 	"go-clean-template/internal/facade/httpserver/handler"
 	"go-clean-template/internal/facade/httpserver/middleware"
+	"go-clean-template/internal/facade/httpserver/problem"
+	"go-clean-template/internal/facade/httpserver/sse"
+	"go-clean-template/pkg/idempotency"
 	"go-clean-template/pkg/logger"
 	"go-clean-template/pkg/monitoring"
+	"go-clean-template/pkg/openapi"
+	"go-clean-template/pkg/ratelimit"
 	"net/http"
-	"net/http/pprof"
 
 	"github.com/gorilla/mux"
 )
 
 type Provider interface {
 	GetService() domain.Service
+	GetJobService() domain.JobService
+	GetBackfillService() domain.BackfillService
 	GetAppVersion() string
+	GetAuditService() domain.AuditService
+	GetAuthService() domain.AuthService
+	GetPolicyService() domain.PolicyService
+	GetEnv() string
+	GetConfig() *config.Config
+	ReloadConfig(ctx context.Context) (*config.Config, error)
+	OnConfigReload(fn func(cfg *config.Config))
+	GetIdempotencyRepository() idempotency.Repository
+	GetRateLimitStore() ratelimit.Store
+	GetEventBus() domain.EventBus
+	GetEventRelay() domain.EventRelay
 	GetMonitoring() monitoring.Monitoring
 	GetLogger() logger.Logger
 }
 
+type Middleware interface {
+	Guard
+	Limiter
+	Cacher
+	Auditor
+	CorsMiddleware(h http.Handler) http.Handler
+	RequestIDMiddleware(h http.Handler) http.Handler
+	CompressionMiddleware(h http.Handler) http.Handler
+	VersionMiddleware(h http.Handler) http.Handler
+	RecoverMiddleware(h http.Handler) http.Handler
+	ClientCertMiddleware(h http.Handler) http.Handler
+	AdminActorMiddleware(h http.Handler) http.Handler
+	RequestLogger(h http.Handler) http.Handler
+	LimitMiddleware(h http.Handler) http.Handler
+	AuthMiddleware(h http.Handler) http.Handler
+	RateLimitMiddleware(h http.Handler) http.Handler
+	AdmissionMiddleware(h http.Handler) http.Handler
+	ValidationMiddleware(h http.Handler) http.Handler
+	MonitoringMiddleware(h http.Handler) http.Handler
+	AuditMiddleware(h http.Handler) http.Handler
+	IdempotencyMiddleware(h http.Handler) http.Handler
+	UseOpenAPI(doc *openapi.Document)
+}
+
+// Broker serves the event streams of the router, the server closes it on stop
+type Broker interface {
+	handler.Broker
+	Close(ctx context.Context) error
+}
+
 type router struct {
-	root *mux.Router
-	prov Provider
+	root    *mux.Router
+	routes  *Routes
+	mw      Middleware
+	streams Broker
+	prov    Provider
 }
 
-func New(prov Provider) *router {
+func New(cfg config.HTTP, prov Provider) *router {
 	root := mux.NewRouter()
-
-	v1Prefix := "/api/v1"
-	RegisterDomainHandlers(prov, root, v1Prefix)
+	mw := middleware.New(cfg, prov)
+	prov.OnConfigReload(func(cfg *config.Config) {
+		mw.ApplyCORS(cfg.HTTP.CORS)
+		mw.ApplyLogging(cfg.HTTP.Logging)
+	})
+	rs := NewRoutes(root, mw, mw, mw, mw)
+	streams := sse.New("streams", cfg.Streams, prov.GetMonitoring(), prov.GetLogger())
 
 	r := router{
 		root,
+		rs,
+		mw,
+		streams,
 		prov,
 	}
 
-	r.initPprofHandlers()
-	r.initProbesHandlers()
+	r.registerVersions()
+	RegisterJobStream(prov, rs, streams, versionPrefix(apiV1), WithScopes(domain.ScopeJobsRead))
+	r.initUtilHandlers()
+	r.initOpenAPI(prov.GetConfig().AppName, cfg.OpenAPI, cfg.Auth)
 	r.initMiddlewares()
+	r.initErrorHandlers()
 
 	return &r
 }
 
+// Router returns the root router wrapped in CORS and version negotiation, both happen before routing.
+// The request ID is set outside of them, so their answers and those of the error handlers carry it.
 func (r *router) Router() http.Handler {
-	return r.root
+	return r.mw.RequestIDMiddleware(r.mw.CorsMiddleware(r.negotiateVersion(r.root)))
 }
 
-func (r *router) initPprofHandlers() {
-	debugPrefix := "/debug/pprof"
-	r.root.HandleFunc(debugPrefix+"/", pprof.Index)
-	r.root.HandleFunc(debugPrefix+"/cmdline", pprof.Cmdline)
-	r.root.HandleFunc(debugPrefix+"/symbol", pprof.Symbol)
-	r.root.HandleFunc(debugPrefix+"/trace", pprof.Trace)
-
-	profilePrefix := "/profile"
-	r.root.HandleFunc(profilePrefix+"", pprof.Profile)
-	r.root.Handle(profilePrefix+"/goroutine", pprof.Handler("goroutine"))
-	r.root.Handle(profilePrefix+"/threadcreate", pprof.Handler("threadcreate"))
-	r.root.Handle(profilePrefix+"/heap", pprof.Handler("heap"))
-	r.root.Handle(profilePrefix+"/block", pprof.Handler("block"))
-	r.root.Handle(profilePrefix+"/mutex", pprof.Handler("mutex"))
+// Streams returns the broker of event streams, nil if the router has none
+func (r *router) Streams() Broker {
+	return r.streams
 }
 
-func (r *router) initProbesHandlers() {
-	h := handler.New(r.prov)
-
-	apiPrefix := "/api"
-	r.root.HandleFunc(apiPrefix+"/version", h.GetVersion)
-	r.root.HandleFunc(apiPrefix+"/utc", h.GetTimeInUTC)
-	r.root.HandleFunc(apiPrefix+"/live", h.GetNoContent)
-	r.root.HandleFunc(apiPrefix+"/ready", h.GetNoContent)
+// Routes returns the metadata of the registered routes
+func (r *router) Routes() []Route {
+	return r.routes.Routes()
+}
 
-	r.root.Handle("/metrics", r.prov.GetMonitoring().GetMetricsHandler())
+func (r *router) initUtilHandlers() {
+	h := handler.New(r.prov)
+	r.routes.Handle(http.MethodGet, "/api/utc", h.GetTimeInUTC, Public(), WithSummary("Current time in UTC"),
+		WithResponse(http.StatusOK, map[string]string{}))
 }
 
 func (r *router) initMiddlewares() {
-	mw := middleware.New(r.prov)
-	r.root.Use(mw.RecoverMiddleware)
-	r.root.Use(mw.RequestLogger)
-	r.root.Use(mw.ValidationMiddleware)
-	r.root.Use(mw.MonitoringMiddleware)
+	r.root.Use(r.mw.CompressionMiddleware)
+	r.root.Use(r.mw.RecoverMiddleware)
+	r.root.Use(r.mw.ClientCertMiddleware)
+	r.root.Use(r.mw.RequestLogger)
+	r.root.Use(r.mw.VersionMiddleware)
+	r.root.Use(r.mw.LimitMiddleware)
+	r.root.Use(r.mw.AuthMiddleware)
+	r.root.Use(r.mw.RateLimitMiddleware)
+	r.root.Use(r.mw.AdmissionMiddleware)
+	r.root.Use(r.mw.ValidationMiddleware)
+	r.root.Use(r.mw.MonitoringMiddleware)
+	r.root.Use(r.mw.AuditMiddleware)
+	r.root.Use(r.mw.IdempotencyMiddleware)
+}
+
+func (r *router) initErrorHandlers() {
+	errs := problem.New(r.prov)
+	r.root.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		errs.Write(w, req, http.StatusNotFound, domain.CodeRouteNotFound, domain.Params{"path": req.URL.Path})
+	})
+	r.root.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		errs.Write(w, req, http.StatusMethodNotAllowed, domain.CodeMethodNotAllowed,
+			domain.Params{"method": req.Method, "path": req.URL.Path})
+	})
 }
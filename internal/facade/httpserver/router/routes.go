@@ -0,0 +1,181 @@
+package router
+
+import (
+	"go-clean-template/pkg/listquery"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Route is the metadata of a registered route
+type Route struct {
+	Method string
+	Path   string
+	// Public routes are served without authentication
+	Public bool
+	// Scopes are required from the principal of the request
+	Scopes []string
+	// MaxBodySize, Timeout and BodyReadTimeout override the limits of config, negative turns them off
+	MaxBodySize     int64
+	Timeout         time.Duration
+	BodyReadTimeout time.Duration
+	// CacheControl is sent with GET responses that don't set it, CacheTTL keeps them in the server cache
+	// until it expires or an event of InvalidateOn is published
+	CacheControl string
+	CacheTTL     time.Duration
+	InvalidateOn []string
+	// Summary, Request and Responses describe the route in the OpenAPI spec
+	Summary   string
+	Request   reflect.Type
+	Responses map[int]reflect.Type
+	// List documents the list parameters of the route
+	List *listquery.Spec
+	// AuditState loads the resource state that is hashed before and after state-changing requests
+	AuditState func(r *http.Request) (any, error)
+}
+
+type RouteOption func(r *Route)
+
+func WithScopes(scopes ...string) RouteOption {
+	return func(r *Route) {
+		r.Scopes = append(r.Scopes, scopes...)
+	}
+}
+
+func Public() RouteOption {
+	return func(r *Route) {
+		r.Public = true
+	}
+}
+
+func WithMaxBodySize(n int64) RouteOption {
+	return func(r *Route) {
+		r.MaxBodySize = n
+	}
+}
+
+func WithTimeout(d time.Duration) RouteOption {
+	return func(r *Route) {
+		r.Timeout = d
+	}
+}
+
+func WithBodyReadTimeout(d time.Duration) RouteOption {
+	return func(r *Route) {
+		r.BodyReadTimeout = d
+	}
+}
+
+func WithCacheControl(value string) RouteOption {
+	return func(r *Route) {
+		r.CacheControl = value
+	}
+}
+
+// WithServerCache stores responses for ttl, they are stored per principal on authenticated requests
+func WithServerCache(ttl time.Duration, invalidateOn ...string) RouteOption {
+	return func(r *Route) {
+		r.CacheTTL = ttl
+		r.InvalidateOn = append(r.InvalidateOn, invalidateOn...)
+	}
+}
+
+// WithAuditState sets the loader of the audited resource state, routes that create resources don't need it
+func WithAuditState(state func(r *http.Request) (any, error)) RouteOption {
+	return func(r *Route) {
+		r.AuditState = state
+	}
+}
+
+func WithSummary(summary string) RouteOption {
+	return func(r *Route) {
+		r.Summary = summary
+	}
+}
+
+// WithRequest documents the DTO bound by the handler: its JSON body and path, query and header parameters
+func WithRequest(v any) RouteOption {
+	return func(r *Route) {
+		r.Request = reflect.TypeOf(v)
+	}
+}
+
+// WithResponse documents the JSON body of a response status, nil documents a response without body
+func WithResponse(status int, v any) RouteOption {
+	return func(r *Route) {
+		if r.Responses == nil {
+			r.Responses = make(map[int]reflect.Type)
+		}
+		r.Responses[status] = reflect.TypeOf(v)
+	}
+}
+
+func WithList(spec *listquery.Spec) RouteOption {
+	return func(r *Route) {
+		r.List = spec
+	}
+}
+
+type Guard interface {
+	Authorize(method, path string, public bool, scopes []string, h http.Handler) http.Handler
+}
+
+type Limiter interface {
+	LimitRoute(method, path string, maxBodySize int64, timeout, bodyReadTimeout time.Duration)
+}
+
+type Cacher interface {
+	CacheRoute(method, path, cacheControl string, ttl time.Duration, invalidateOn []string,
+		h http.Handler) http.Handler
+}
+
+type Auditor interface {
+	AuditRoute(method, path string, state func(r *http.Request) (any, error))
+}
+
+// Routes registers handlers on the mux together with their metadata
+type Routes struct {
+	root    *mux.Router
+	guard   Guard
+	limiter Limiter
+	cacher  Cacher
+	auditor Auditor
+	routes  []Route
+}
+
+func NewRoutes(root *mux.Router, guard Guard, limiter Limiter, cacher Cacher, auditor Auditor) *Routes {
+	return &Routes{
+		root:    root,
+		guard:   guard,
+		limiter: limiter,
+		cacher:  cacher,
+		auditor: auditor,
+	}
+}
+
+func (rs *Routes) Handle(method, path string, h http.HandlerFunc, opts ...RouteOption) *mux.Route {
+	route := Route{Method: method, Path: path}
+	for _, opt := range opts {
+		opt(&route)
+	}
+	rs.routes = append(rs.routes, route)
+	rs.limiter.LimitRoute(method, path, route.MaxBodySize, route.Timeout, route.BodyReadTimeout)
+	if route.AuditState != nil {
+		rs.auditor.AuditRoute(method, path, route.AuditState)
+	}
+
+	var handler http.Handler = h
+	if method == http.MethodGet {
+		// cached responses are served after the guard
+		handler = rs.cacher.CacheRoute(method, path, route.CacheControl, route.CacheTTL, route.InvalidateOn, h)
+	}
+	return rs.root.Handle(path, rs.guard.Authorize(method, path, route.Public, route.Scopes, handler)).
+		Methods(method)
+}
+
+// Routes returns the registered routes in registration order
+func (rs *Routes) Routes() []Route {
+	return rs.routes
+}
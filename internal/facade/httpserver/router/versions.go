@@ -0,0 +1,110 @@
+package router
+
+import (
+	"errors"
+	"go-clean-template/internal/domain"
+	"go-clean-template/internal/facade/httpserver/problem"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	apiPrefix = "/api"
+	apiV1     = "v1"
+)
+
+type registerFunc func(prov Provider, rs *Routes, prefix string)
+
+// apiVersion lists the handlers served under /api/{name}. A new version registers the changed handlers
+// and reuses the rest, the old one stays until its sunset in config.
+type apiVersion struct {
+	name     string
+	handlers []registerFunc
+}
+
+//nolint:gochecknoglobals //versions of the public API
+var apiVersions = []apiVersion{
+	{name: apiV1, handlers: []registerFunc{RegisterDomainHandlers, RegisterErrorCatalogHandlers}},
+}
+
+func versionPrefix(version string) string {
+	return apiPrefix + "/" + version
+}
+
+func (r *router) registerVersions() {
+	for _, v := range apiVersions {
+		for _, register := range v.handlers {
+			register(r.prov, r.routes, versionPrefix(v.name))
+		}
+	}
+}
+
+// negotiateVersion routes /api paths without a version to the version named by Accept or to the default one.
+// A version in the path wins over Accept, paths that no version serves are left as they are.
+func (r *router) negotiateVersion(h http.Handler) http.Handler {
+	errs := problem.New(r.prov)
+	def := r.prov.GetConfig().HTTP.Versions.Default
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rest, ok := strings.CutPrefix(req.URL.Path, apiPrefix+"/")
+		if !ok || slices.ContainsFunc(apiVersions, func(v apiVersion) bool {
+			return rest == v.name || strings.HasPrefix(rest, v.name+"/")
+		}) {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		version := acceptedVersion(req.Header.Values("Accept"))
+		if version != "" && !slices.ContainsFunc(apiVersions, func(v apiVersion) bool { return v.name == version }) {
+			errs.Write(w, req, http.StatusNotAcceptable, domain.CodeVersionNotFound, domain.Params{"version": version})
+			return
+		}
+		if version == "" {
+			version = def
+		}
+		if version == "" {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		versioned := req.Clone(req.Context())
+		versioned.URL.Path = versionPrefix(version) + "/" + rest
+		versioned.URL.RawPath = ""
+		var match mux.RouteMatch
+		if !r.root.Match(versioned, &match) || errors.Is(match.MatchErr, mux.ErrNotFound) {
+			h.ServeHTTP(w, req)
+			return
+		}
+		w.Header().Add("Vary", "Accept")
+		h.ServeHTTP(w, versioned)
+	})
+}
+
+// acceptedVersion returns the version of application/vnd.<app>.v2+json or application/json; version=2
+func acceptedVersion(accept []string) string {
+	for _, value := range accept {
+		for _, part := range strings.Split(value, ",") {
+			mediaType, params, err := mime.ParseMediaType(part)
+			if err != nil {
+				continue
+			}
+			if v := params["version"]; v != "" {
+				if !strings.HasPrefix(v, "v") {
+					v = "v" + v
+				}
+				return v
+			}
+			if vnd, ok := strings.CutPrefix(mediaType, "application/vnd."); ok {
+				if vnd, ok = strings.CutSuffix(vnd, "+json"); ok {
+					if i := strings.LastIndex(vnd, "."); i >= 0 && strings.HasPrefix(vnd[i+1:], "v") {
+						return vnd[i+1:]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,271 @@
+// Package sse serves Server-Sent Events. Handlers publish to named streams of a broker and subscribe
+// clients with Serve. Every stream keeps a bounded replay buffer, a client that reconnects with
+// Last-Event-ID gets the events it missed, or a reset event if they are no longer buffered.
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-clean-template/config"
+	"go-clean-template/pkg/logger"
+	"go-clean-template/pkg/monitoring"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// EventReset tells a resuming client that events were lost, it has to reload the state
+	EventReset = "reset"
+
+	defaultHeartbeat    = 15 * time.Second
+	defaultClientBuffer = 64
+)
+
+var ErrClosed = errors.New("broker is closed")
+
+type Event struct {
+	ID   string
+	Name string
+	Data []byte
+}
+
+type client struct {
+	events chan Event
+	// done is closed when the client is dropped or the broker is closed
+	done chan struct{}
+}
+
+type stream struct {
+	seq     uint64
+	replay  []Event
+	clients map[*client]struct{}
+}
+
+type broker struct {
+	cfg config.Streams
+	// metrics names the counter of events, the gauge of clients is named metrics+"_clients"
+	metrics string
+	clients string
+	// epoch tells ids of this process from ids issued before a restart
+	epoch   string
+	mu      sync.Mutex
+	streams map[string]*stream
+	closed  bool
+	wg      sync.WaitGroup
+	mon     monitoring.Monitoring
+	lg      logger.Logger
+}
+
+// New creates a broker, name prefixes its metrics and has to be unique in the process
+func New(name string, cfg config.Streams, mon monitoring.Monitoring, lg logger.Logger) *broker {
+	if cfg.Heartbeat <= 0 {
+		cfg.Heartbeat = defaultHeartbeat
+	}
+	if cfg.ClientBuffer <= 0 {
+		cfg.ClientBuffer = defaultClientBuffer
+	}
+	mon.Register(name)
+	mon.RegisterGauge(name + "_clients")
+	return &broker{
+		cfg:     cfg,
+		metrics: name,
+		clients: name + "_clients",
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		streams: make(map[string]*stream),
+		mon:     mon,
+		lg:      lg,
+	}
+}
+
+// Publish sends v as JSON to the clients of the stream. It never blocks, a client with a full queue
+// is disconnected and resumes from the replay buffer.
+func (b *broker) Publish(name, event string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("broker.Publish: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := b.stream(name)
+	s.seq++
+	e := Event{ID: b.epoch + "-" + strconv.FormatUint(s.seq, 10), Name: event, Data: data}
+	if b.cfg.Replay > 0 {
+		if len(s.replay) == b.cfg.Replay {
+			s.replay = append(s.replay[:0], s.replay[1:]...)
+		}
+		s.replay = append(s.replay, e)
+	}
+
+	for c := range s.clients {
+		select {
+		case c.events <- e:
+		default:
+			b.drop(s, c)
+			b.mon.Count(b.metrics, name+" slow client", true)
+		}
+	}
+	b.mon.Count(b.metrics, name+" "+event, false)
+	return nil
+}
+
+// Serve streams events of the stream until the client goes away, the client falls behind or the broker
+// is closed. It returns ErrClosed before anything is written if the broker is closed.
+func (b *broker) Serve(w http.ResponseWriter, r *http.Request, name string) error {
+	rc := http.NewResponseController(w)
+	// the write timeout of the server would cut the stream
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	c := &client{events: make(chan Event, b.cfg.ClientBuffer), done: make(chan struct{})}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	s := b.stream(name)
+	missed := b.missed(s, r.Header.Get("Last-Event-ID"))
+	s.clients[c] = struct{}{}
+	b.wg.Add(1)
+	b.mu.Unlock()
+	b.mon.Set(b.clients, name, float64(b.count(name)))
+
+	defer func() {
+		b.mu.Lock()
+		b.drop(s, c)
+		b.mu.Unlock()
+		b.mon.Set(b.clients, name, float64(b.count(name)))
+		b.wg.Done()
+	}()
+
+	hdr := w.Header()
+	hdr.Set("Content-Type", "text/event-stream")
+	hdr.Set("Cache-Control", "no-cache")
+	// proxies must not buffer the stream
+	hdr.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	var buf strings.Builder
+	if b.cfg.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(b.cfg.Retry.Milliseconds(), 10) + "\n\n")
+	}
+	for _, e := range missed {
+		writeEvent(&buf, e)
+	}
+	if err := send(w, rc, buf.String()); err != nil {
+		return fmt.Errorf("broker.Serve: %w", err)
+	}
+
+	heartbeat := time.NewTicker(b.cfg.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		buf.Reset()
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-c.done:
+			return nil
+		case e := <-c.events:
+			writeEvent(&buf, e)
+		case <-heartbeat.C:
+			buf.WriteString(": heartbeat\n\n")
+		}
+		if err := send(w, rc, buf.String()); err != nil {
+			return fmt.Errorf("broker.Serve: %w", err)
+		}
+	}
+}
+
+// Close disconnects all clients and waits for their handlers, so the server shutdown is not held
+// by open streams. Clients reconnect to another instance after the retry delay.
+func (b *broker) Close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	for _, s := range b.streams {
+		for c := range s.clients {
+			b.drop(s, c)
+		}
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("broker.Close: %w", ctx.Err())
+	}
+}
+
+// stream returns the stream by name, b.mu must be held
+func (b *broker) stream(name string) *stream {
+	s, ok := b.streams[name]
+	if !ok {
+		s = &stream{clients: make(map[*client]struct{})}
+		b.streams[name] = s
+	}
+	return s
+}
+
+// drop removes the client from the stream, b.mu must be held
+func (b *broker) drop(s *stream, c *client) {
+	if _, ok := s.clients[c]; ok {
+		delete(s.clients, c)
+		close(c.done)
+	}
+}
+
+func (b *broker) count(name string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.streams[name].clients)
+}
+
+// missed returns the events after lastID, b.mu must be held. An id of another process or an id that
+// has left the replay buffer gets a reset event.
+func (b *broker) missed(s *stream, lastID string) []Event {
+	if lastID == "" {
+		return nil
+	}
+	reset := []Event{{Name: EventReset, Data: []byte("{}")}}
+	epoch, seq, ok := strings.Cut(lastID, "-")
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if !ok || err != nil || epoch != b.epoch || n > s.seq {
+		return reset
+	}
+	// the replay holds the events s.seq-len(replay)+1 .. s.seq
+	first := s.seq - uint64(len(s.replay)) + 1
+	if n+1 < first {
+		return reset
+	}
+	return append([]Event(nil), s.replay[n+1-first:]...)
+}
+
+func writeEvent(buf *strings.Builder, e Event) {
+	if e.ID != "" {
+		buf.WriteString("id: " + e.ID + "\n")
+	}
+	buf.WriteString("event: " + e.Name + "\n")
+	// JSON has no raw newlines, but a payload with them needs a data line per line
+	for _, line := range strings.Split(string(e.Data), "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteString("\n")
+}
+
+func send(w http.ResponseWriter, rc *http.ResponseController, s string) error {
+	if s != "" {
+		if _, err := w.Write([]byte(s)); err != nil {
+			return err
+		}
+	}
+	return rc.Flush()
+}
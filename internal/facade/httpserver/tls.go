@@ -0,0 +1,99 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"go-clean-template/config"
+	"go-clean-template/pkg/logger"
+	"go-clean-template/pkg/tlsreload"
+)
+
+var tlsVersions = map[string]uint16{ //nolint:gochecknoglobals //config values
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+	"":    tls.VersionTLS12,
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{ //nolint:gochecknoglobals //config values
+	"":                   tls.NoClientCert,
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify-if-given":    tls.VerifyClientCertIfGiven,
+	"require-and-verify": tls.RequireAndVerifyClientCert,
+}
+
+type CertReloader interface {
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+	Run(ctx context.Context)
+}
+
+// newTLSConfig builds the server config, the certificate and the client CA bundle
+// are taken from the reloader on every handshake
+func newTLSConfig(cfg config.TLS, lg logger.Logger) (*tls.Config, CertReloader, error) {
+	const op = "httpserver.newTLSConfig"
+
+	minVersion, ok := tlsVersions[cfg.MinVersion]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: unknown min-version %q", op, cfg.MinVersion)
+	}
+	clientAuth, ok := clientAuthTypes[cfg.ClientAuth]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: unknown client-auth %q", op, cfg.ClientAuth)
+	}
+	if clientAuth >= tls.VerifyClientCertIfGiven && cfg.ClientCAFile == "" {
+		return nil, nil, fmt.Errorf("%s: client-auth %s needs client-ca-file", op, cfg.ClientAuth)
+	}
+	suites, err := cipherSuites(cfg.CipherSuites)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rl, err := tlsreload.New(cfg.CertFile, cfg.KeyFile, cfg.ClientCAFile, cfg.ReloadInterval, lg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	base := &tls.Config{
+		MinVersion:     minVersion,
+		CipherSuites:   suites,
+		ClientAuth:     clientAuth,
+		GetCertificate: rl.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+	if cfg.ClientCAFile == "" {
+		return base, rl, nil
+	}
+
+	tlsCfg := base.Clone()
+	tlsCfg.GetConfigForClient = func(_ *tls.ClientHelloInfo) (*tls.Config, error) {
+		c := base.Clone()
+		c.ClientCAs = rl.ClientCAs()
+		return c, nil
+	}
+	return tlsCfg, rl, nil
+}
+
+func cipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		known[s.Name] = s.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[name]
+		if !ok {
+			return nil, errors.New("unknown or insecure cipher suite " + name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
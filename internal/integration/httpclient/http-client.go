@@ -4,10 +4,28 @@ import (
 	"net/http"
 
 	"go-clean-template/config"
+	"go-clean-template/pkg/logger"
 )
 
 func New(cfg config.HTTPClient) *http.Client {
 	return &http.Client{
-		Timeout: cfg.Timeout,
+		Timeout:   cfg.Timeout,
+		Transport: requestIDTransport{http.DefaultTransport},
 	}
 }
+
+// requestIDTransport passes the request ID of the context to the called service
+type requestIDTransport struct {
+	next http.RoundTripper
+}
+
+func (t requestIDTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	reqID := logger.RequestIDFromContext(r.Context())
+	if reqID == "" || r.Header.Get(logger.HeaderRequestID) != "" {
+		return t.next.RoundTrip(r)
+	}
+	// a RoundTripper must not modify the request
+	r = r.Clone(r.Context())
+	r.Header.Set(logger.HeaderRequestID, reqID)
+	return t.next.RoundTrip(r)
+}
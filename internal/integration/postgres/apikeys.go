@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-clean-template/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type apiKeyRepo struct {
+	pool DB
+}
+
+func NewAPIKeyRepo(pool DB) *apiKeyRepo {
+	return &apiKeyRepo{
+		pool,
+	}
+}
+
+func (r *apiKeyRepo) FindByHash(ctx context.Context, hash string) (domain.APIKey, error) {
+	const op = "apiKeyRepo.FindByHash"
+
+	key := domain.APIKey{}
+	err := r.pool.QueryRow(ctx, `
+SELECT id, subject, scopes, expires_at, revoked_at
+FROM schema_.api_keys
+WHERE key_hash = $1`, hash).Scan(&key.ID, &key.Subject, &key.Scopes, &key.ExpiresAt, &key.RevokedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.APIKey{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.APIKey{}, fmt.Errorf("%s: row.Scan: %w", op, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/listquery"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type auditRepo struct {
+	pool DB
+}
+
+func NewAuditRepo(pool DB) *auditRepo {
+	return &auditRepo{
+		pool,
+	}
+}
+
+func (r *auditRepo) Insert(ctx context.Context, e domain.AuditEntry) error {
+	const op = "auditRepo.Insert"
+
+	_, err := r.pool.Exec(ctx, `
+INSERT INTO schema_.audit_log (id, actor, action, method, route, resource_id, before_hash, after_hash,
+                               request_id, status, outcome, error, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		e.ID, e.Actor, e.Action, e.Method, e.Route, e.ResourceID, e.BeforeHash, e.AfterHash,
+		e.RequestID, e.Status, e.Outcome, e.Error, e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: r.pool.Exec: %w", op, err)
+	}
+	return nil
+}
+
+func (r *auditRepo) List(ctx context.Context, q listquery.Query) ([]domain.AuditEntry, error) {
+	const op = "auditRepo.List"
+
+	query, args := q.Build(`
+SELECT id, actor, action, method, route, resource_id, before_hash, after_hash,
+       request_id, status, outcome, error, created_at
+FROM schema_.audit_log`, nil, nil)
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: r.pool.Query: %w", op, err)
+	}
+
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.AuditEntry, error) {
+		e := domain.AuditEntry{}
+		err := row.Scan(&e.ID, &e.Actor, &e.Action, &e.Method, &e.Route, &e.ResourceID, &e.BeforeHash,
+			&e.AfterHash, &e.RequestID, &e.Status, &e.Outcome, &e.Error, &e.CreatedAt)
+		return e, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: pgx.CollectRows: %w", op, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,291 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-clean-template/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type backfillRepo struct {
+	pool DB
+}
+
+func NewBackfillRepo(pool DB) *backfillRepo {
+	return &backfillRepo{
+		pool,
+	}
+}
+
+const selectBackfillRun = `
+SELECT r.id, r.date_from::text, r.date_to::text, r.status, r.created_at, r.updated_at,
+       count(d.dt),
+       count(d.dt) FILTER (WHERE d.status = 'done'),
+       count(d.dt) FILTER (WHERE d.status = 'failed')
+FROM schema_.backfill_runs r
+LEFT JOIN schema_.backfill_days d ON d.run_id = r.id`
+
+func (r *backfillRepo) CreateRun(ctx context.Context, run domain.BackfillRun) error {
+	const op = "backfillRepo.CreateRun"
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: r.pool.Begin: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	_, err = tx.Exec(ctx, `
+INSERT INTO schema_.backfill_runs (id, date_from, date_to, status)
+VALUES ($1, $2::date, $3::date, $4)`,
+		run.ID, run.DateFrom, run.DateTo, run.Status)
+	if err != nil {
+		return fmt.Errorf("%s: insert run: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+INSERT INTO schema_.backfill_days (run_id, dt, status)
+SELECT $1, d::date, $4
+FROM generate_series($2::date, $3::date, interval '1 day') d`,
+		run.ID, run.DateFrom, run.DateTo, domain.BackfillStatusPending)
+	if err != nil {
+		return fmt.Errorf("%s: insert days: %w", op, err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: tx.Commit: %w", op, err)
+	}
+	return nil
+}
+
+func (r *backfillRepo) FindUnfinishedRun(ctx context.Context, from, to string) (domain.BackfillRun, error) {
+	const op = "backfillRepo.FindUnfinishedRun"
+
+	row := r.pool.QueryRow(ctx, selectBackfillRun+`
+WHERE r.date_from = $1::date AND r.date_to = $2::date AND r.status <> $3
+GROUP BY r.id
+ORDER BY r.created_at DESC
+LIMIT 1`, from, to, domain.BackfillStatusDone)
+
+	run, err := scanBackfillRun(row)
+	if err != nil {
+		return domain.BackfillRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return run, nil
+}
+
+func (r *backfillRepo) GetRun(ctx context.Context, id uuid.UUID) (domain.BackfillRun, error) {
+	const op = "backfillRepo.GetRun"
+
+	row := r.pool.QueryRow(ctx, selectBackfillRun+`
+WHERE r.id = $1
+GROUP BY r.id`, id)
+
+	run, err := scanBackfillRun(row)
+	if err != nil {
+		return domain.BackfillRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return run, nil
+}
+
+func (r *backfillRepo) ListRuns(ctx context.Context, limit int) ([]domain.BackfillRun, error) {
+	const op = "backfillRepo.ListRuns"
+
+	rows, err := r.pool.Query(ctx, selectBackfillRun+`
+GROUP BY r.id
+ORDER BY r.created_at DESC
+LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: r.pool.Query: %w", op, err)
+	}
+	defer rows.Close()
+
+	runs := make([]domain.BackfillRun, 0)
+	for rows.Next() {
+		run, err := scanBackfillRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		runs = append(runs, run)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows.Err: %w", op, err)
+	}
+	return runs, nil
+}
+
+func (r *backfillRepo) ListDays(ctx context.Context, id uuid.UUID) ([]domain.BackfillDay, error) {
+	const op = "backfillRepo.ListDays"
+
+	rows, err := r.pool.Query(ctx, `
+SELECT dt::text, status, attempts, error, started_at, finished_at
+FROM schema_.backfill_days
+WHERE run_id = $1
+ORDER BY dt`, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: r.pool.Query: %w", op, err)
+	}
+	defer rows.Close()
+
+	days := make([]domain.BackfillDay, 0)
+	for rows.Next() {
+		day := domain.BackfillDay{}
+		err = rows.Scan(&day.Date, &day.Status, &day.Attempts, &day.Error, &day.StartedAt, &day.FinishedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: rows.Scan: %w", op, err)
+		}
+		days = append(days, day)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows.Err: %w", op, err)
+	}
+	return days, nil
+}
+
+func (r *backfillRepo) PendingDays(ctx context.Context, id uuid.UUID) ([]string, error) {
+	const op = "backfillRepo.PendingDays"
+
+	rows, err := r.pool.Query(ctx, `
+SELECT dt::text
+FROM schema_.backfill_days
+WHERE run_id = $1 AND status <> $2
+ORDER BY dt`, id, domain.BackfillStatusDone)
+	if err != nil {
+		return nil, fmt.Errorf("%s: r.pool.Query: %w", op, err)
+	}
+
+	days, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("%s: pgx.CollectRows: %w", op, err)
+	}
+	return days, nil
+}
+
+func (r *backfillRepo) SetRunStatus(ctx context.Context, id uuid.UUID, status domain.BackfillStatus) error {
+	const op = "backfillRepo.SetRunStatus"
+
+	_, err := r.pool.Exec(ctx, `
+UPDATE schema_.backfill_runs SET status = $2, updated_at = now() WHERE id = $1`, id, status)
+	if err != nil {
+		return fmt.Errorf("%s: r.pool.Exec: %w", op, err)
+	}
+	return nil
+}
+
+// LockRun takes the lease of the run for owner or renews it, false if another owner holds a live lease
+func (r *backfillRepo) LockRun(ctx context.Context, id, owner uuid.UUID, ttl time.Duration) (bool, error) {
+	const op = "backfillRepo.LockRun"
+
+	tag, err := r.pool.Exec(ctx, `
+UPDATE schema_.backfill_runs SET locked_by = $2, locked_until = now() + make_interval(secs => $3)
+WHERE id = $1 AND (locked_until IS NULL OR locked_until < now() OR locked_by = $2)`,
+		id, owner, ttl.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("%s: r.pool.Exec: %w", op, err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *backfillRepo) UnlockRun(ctx context.Context, id, owner uuid.UUID) error {
+	const op = "backfillRepo.UnlockRun"
+
+	_, err := r.pool.Exec(ctx, `
+UPDATE schema_.backfill_runs SET locked_by = NULL, locked_until = NULL
+WHERE id = $1 AND locked_by = $2`, id, owner)
+	if err != nil {
+		return fmt.Errorf("%s: r.pool.Exec: %w", op, err)
+	}
+	return nil
+}
+
+// ResetInterrupted puts days left running by a process that died back to pending and stops their runs.
+// Only runs without a live lease are touched, so runs of other instances are not.
+func (r *backfillRepo) ResetInterrupted(ctx context.Context) (int64, error) {
+	const op = "backfillRepo.ResetInterrupted"
+
+	tag, err := r.pool.Exec(ctx, `
+WITH runs AS (
+	UPDATE schema_.backfill_runs
+	SET status = $2, locked_by = NULL, locked_until = NULL, updated_at = now()
+	WHERE status = $1 AND (locked_until IS NULL OR locked_until < now())
+	RETURNING id
+)
+UPDATE schema_.backfill_days SET status = $3, finished_at = NULL
+WHERE status = $1 AND run_id IN (SELECT id FROM runs)`,
+		domain.BackfillStatusRunning, domain.BackfillStatusStopped, domain.BackfillStatusPending)
+	if err != nil {
+		return 0, fmt.Errorf("%s: r.pool.Exec: %w", op, err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ResetRunningDays puts running days of a run back to pending, the caller holds the lease of the run,
+// so they were left by an interrupted process
+func (r *backfillRepo) ResetRunningDays(ctx context.Context, id uuid.UUID) error {
+	const op = "backfillRepo.ResetRunningDays"
+
+	_, err := r.pool.Exec(ctx, `
+UPDATE schema_.backfill_days SET status = $3, finished_at = NULL
+WHERE run_id = $1 AND status = $2`, id, domain.BackfillStatusRunning, domain.BackfillStatusPending)
+	if err != nil {
+		return fmt.Errorf("%s: r.pool.Exec: %w", op, err)
+	}
+	return nil
+}
+
+func (r *backfillRepo) StartDay(ctx context.Context, id uuid.UUID, dt string) error {
+	const op = "backfillRepo.StartDay"
+
+	// the run is touched too, its updated_at versions the days for conditional requests
+	_, err := r.pool.Exec(ctx, `
+WITH d AS (
+	UPDATE schema_.backfill_days
+	SET status = $3, attempts = attempts + 1, error = '', started_at = now(), finished_at = NULL
+	WHERE run_id = $1 AND dt = $2::date
+	RETURNING run_id
+)
+UPDATE schema_.backfill_runs SET updated_at = now() WHERE id IN (SELECT run_id FROM d)`,
+		id, dt, domain.BackfillStatusRunning)
+	if err != nil {
+		return fmt.Errorf("%s: r.pool.Exec: %w", op, err)
+	}
+	return nil
+}
+
+func (r *backfillRepo) FinishDay(ctx context.Context, id uuid.UUID, dt string,
+	status domain.BackfillStatus, errText string) error {
+	const op = "backfillRepo.FinishDay"
+
+	_, err := r.pool.Exec(ctx, `
+WITH d AS (
+	UPDATE schema_.backfill_days
+	SET status = $3, error = $4, finished_at = now()
+	WHERE run_id = $1 AND dt = $2::date
+	RETURNING run_id
+)
+UPDATE schema_.backfill_runs SET updated_at = now() WHERE id IN (SELECT run_id FROM d)`,
+		id, dt, status, errText)
+	if err != nil {
+		return fmt.Errorf("%s: r.pool.Exec: %w", op, err)
+	}
+	return nil
+}
+
+func scanBackfillRun(row pgx.Row) (domain.BackfillRun, error) {
+	run := domain.BackfillRun{}
+	err := row.Scan(&run.ID, &run.DateFrom, &run.DateTo, &run.Status, &run.CreatedAt, &run.UpdatedAt,
+		&run.Total, &run.Done, &run.Failed)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.BackfillRun{}, domain.NewNotFoundError(domain.CodeBackfillRunNotFound, nil)
+	}
+	if err != nil {
+		return domain.BackfillRun{}, fmt.Errorf("row.Scan: %w", err)
+	}
+	return run, nil
+}
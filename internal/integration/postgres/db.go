@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+
+	"go-clean-template/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DB is the part of pgxpool.Pool used by repositories
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type requestIDDB struct {
+	pool *pgxpool.Pool
+}
+
+// WithRequestID appends the request ID of the context to every statement as a sqlcommenter comment,
+// so slow queries in pg_stat_activity and the server log can be traced back to the request.
+// Annotated statements can't be prepared once, see annotate for the cost.
+func WithRequestID(pool *pgxpool.Pool) DB {
+	return requestIDDB{pool}
+}
+
+func (db requestIDDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	sql, args = annotate(ctx, sql, args)
+	return db.pool.Exec(ctx, sql, args...)
+}
+
+func (db requestIDDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	sql, args = annotate(ctx, sql, args)
+	return db.pool.Query(ctx, sql, args...)
+}
+
+func (db requestIDDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	sql, args = annotate(ctx, sql, args)
+	return db.pool.QueryRow(ctx, sql, args...)
+}
+
+func (db requestIDDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return requestIDTx{tx}, nil
+}
+
+type requestIDTx struct {
+	pgx.Tx
+}
+
+func (tx requestIDTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	sql, args = annotate(ctx, sql, args)
+	return tx.Tx.Exec(ctx, sql, args...)
+}
+
+func (tx requestIDTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	sql, args = annotate(ctx, sql, args)
+	return tx.Tx.Query(ctx, sql, args...)
+}
+
+func (tx requestIDTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	sql, args = annotate(ctx, sql, args)
+	return tx.Tx.QueryRow(ctx, sql, args...)
+}
+
+// annotate leaves statements without a request ID as is. Annotated statements are unique per request,
+// they are executed without the statement cache to not evict the prepared statements of the others.
+// QueryExecModeDescribeExec describes every statement before executing it: a request-scoped query takes
+// two round trips instead of one.
+func annotate(ctx context.Context, sql string, args []any) (string, []any) {
+	reqID := logger.RequestIDFromContext(ctx)
+	if !logger.ValidRequestID(reqID) {
+		return sql, args
+	}
+	return sql + " /*request_id='" + reqID + "'*/", append([]any{pgx.QueryExecModeDescribeExec}, args...)
+}
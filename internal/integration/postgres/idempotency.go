@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-clean-template/pkg/idempotency"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type idempotencyRepo struct {
+	pool DB
+}
+
+func NewIdempotencyRepo(pool DB) *idempotencyRepo {
+	return &idempotencyRepo{
+		pool,
+	}
+}
+
+func (r *idempotencyRepo) Acquire(ctx context.Context, key, requestHash string,
+	ttl, lockTimeout time.Duration) (idempotency.Record, bool, error) {
+	const op = "idempotencyRepo.Acquire"
+
+	// the row may be purged between insert and select, one more attempt is enough
+	for range 2 {
+		var expiresAt time.Time
+		err := r.pool.QueryRow(ctx, `
+INSERT INTO schema_.idempotency_keys (key, request_hash, state, locked_at, expires_at)
+VALUES ($1, $2, $3, now(), now() + make_interval(secs => $4))
+ON CONFLICT (key) DO UPDATE
+SET request_hash = EXCLUDED.request_hash, state = EXCLUDED.state, response_status = 0,
+    response_headers = '{}', response_body = NULL, locked_at = now(), expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at < now()
+   OR (idempotency_keys.state = $3 AND idempotency_keys.locked_at < now() - make_interval(secs => $5))
+RETURNING expires_at`,
+			key, requestHash, idempotency.StateProcessing, ttl.Seconds(), lockTimeout.Seconds()).Scan(&expiresAt)
+		if err == nil {
+			return idempotency.Record{
+				Key:         key,
+				RequestHash: requestHash,
+				State:       idempotency.StateProcessing,
+				ExpiresAt:   expiresAt,
+			}, true, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return idempotency.Record{}, false, fmt.Errorf("%s: insert: %w", op, err)
+		}
+
+		rec := idempotency.Record{}
+		err = r.pool.QueryRow(ctx, `
+SELECT key, request_hash, state, response_status, response_headers, response_body, expires_at
+FROM schema_.idempotency_keys
+WHERE key = $1`, key).Scan(&rec.Key, &rec.RequestHash, &rec.State, &rec.Status, &rec.Header, &rec.Body,
+			&rec.ExpiresAt)
+		if err == nil {
+			return rec, false, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return idempotency.Record{}, false, fmt.Errorf("%s: select: %w", op, err)
+		}
+	}
+
+	return idempotency.Record{}, false, fmt.Errorf("%s: key %s can't be acquired", op, key)
+}
+
+func (r *idempotencyRepo) Complete(ctx context.Context, rec idempotency.Record) error {
+	const op = "idempotencyRepo.Complete"
+
+	_, err := r.pool.Exec(ctx, `
+UPDATE schema_.idempotency_keys
+SET state = $3, response_status = $4, response_headers = $5, response_body = $6
+WHERE key = $1 AND request_hash = $2`,
+		rec.Key, rec.RequestHash, idempotency.StateCompleted, rec.Status, rec.Header, rec.Body)
+	if err != nil {
+		return fmt.Errorf("%s: r.pool.Exec: %w", op, err)
+	}
+	return nil
+}
+
+func (r *idempotencyRepo) Release(ctx context.Context, key string) error {
+	const op = "idempotencyRepo.Release"
+
+	_, err := r.pool.Exec(ctx, `
+DELETE FROM schema_.idempotency_keys WHERE key = $1 AND state = $2`, key, idempotency.StateProcessing)
+	if err != nil {
+		return fmt.Errorf("%s: r.pool.Exec: %w", op, err)
+	}
+	return nil
+}
+
+func (r *idempotencyRepo) Purge(ctx context.Context) (int64, error) {
+	const op = "idempotencyRepo.Purge"
+
+	tag, err := r.pool.Exec(ctx, `DELETE FROM schema_.idempotency_keys WHERE expires_at < now()`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: r.pool.Exec: %w", op, err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/listquery"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type jobRunRepo struct {
+	pool DB
+}
+
+func NewJobRunRepo(pool DB) *jobRunRepo {
+	return &jobRunRepo{
+		pool,
+	}
+}
+
+const selectJobRun = `
+SELECT id, job_name, instance_id, triggered_by, status, params, error, started_at, finished_at
+FROM schema_.job_runs`
+
+func (r *jobRunRepo) Start(ctx context.Context, run domain.JobRun) error {
+	const op = "jobRunRepo.Start"
+
+	_, err := r.pool.Exec(ctx, `
+INSERT INTO schema_.job_runs (id, job_name, instance_id, triggered_by, status, params, started_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		run.ID, run.JobName, run.InstanceID, run.Trigger, run.Status, run.Params, run.StartedAt)
+	if err != nil {
+		return fmt.Errorf("%s: r.pool.Exec: %w", op, err)
+	}
+	return nil
+}
+
+func (r *jobRunRepo) Finish(ctx context.Context, run domain.JobRun) error {
+	const op = "jobRunRepo.Finish"
+
+	_, err := r.pool.Exec(ctx, `
+UPDATE schema_.job_runs SET status = $2, error = $3, finished_at = $4 WHERE id = $1`,
+		run.ID, run.Status, run.Error, run.FinishedAt)
+	if err != nil {
+		return fmt.Errorf("%s: r.pool.Exec: %w", op, err)
+	}
+	return nil
+}
+
+// FailRunning fails the runs of the instance that are still running
+func (r *jobRunRepo) FailRunning(ctx context.Context, instanceID uuid.UUID, reason string) (int64, error) {
+	const op = "jobRunRepo.FailRunning"
+
+	tag, err := r.pool.Exec(ctx, `
+UPDATE schema_.job_runs SET status = $3, error = $4, finished_at = now()
+WHERE instance_id = $1 AND status = $2`,
+		instanceID, domain.JobStatusRunning, domain.JobStatusFailed, reason)
+	if err != nil {
+		return 0, fmt.Errorf("%s: r.pool.Exec: %w", op, err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *jobRunRepo) LastRuns(ctx context.Context) ([]domain.JobRun, error) {
+	const op = "jobRunRepo.LastRuns"
+
+	rows, err := r.pool.Query(ctx, `
+SELECT DISTINCT ON (job_name) id, job_name, instance_id, triggered_by, status, params, error, started_at, finished_at
+FROM schema_.job_runs
+ORDER BY job_name, started_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: r.pool.Query: %w", op, err)
+	}
+
+	runs, err := pgx.CollectRows(rows, scanJobRun)
+	if err != nil {
+		return nil, fmt.Errorf("%s: pgx.CollectRows: %w", op, err)
+	}
+	return runs, nil
+}
+
+func (r *jobRunRepo) Runs(ctx context.Context, name string, q listquery.Query) ([]domain.JobRun, error) {
+	const op = "jobRunRepo.Runs"
+
+	query, args := q.Build(selectJobRun, []string{"job_name = $1"}, []any{name})
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: r.pool.Query: %w", op, err)
+	}
+
+	runs, err := pgx.CollectRows(rows, scanJobRun)
+	if err != nil {
+		return nil, fmt.Errorf("%s: pgx.CollectRows: %w", op, err)
+	}
+	return runs, nil
+}
+
+func scanJobRun(row pgx.CollectableRow) (domain.JobRun, error) {
+	run := domain.JobRun{}
+	err := row.Scan(&run.ID, &run.JobName, &run.InstanceID, &run.Trigger, &run.Status, &run.Params, &run.Error,
+		&run.StartedAt, &run.FinishedAt)
+	if err != nil {
+		return domain.JobRun{}, fmt.Errorf("row.Scan: %w", err)
+	}
+	return run, nil
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-clean-template/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type outboxRepo struct {
+	pool DB
+}
+
+func NewOutboxRepo(pool DB) *outboxRepo {
+	return &outboxRepo{
+		pool,
+	}
+}
+
+// Add joins the transaction of ctx, so the event is stored only if the change it describes is committed
+func (r *outboxRepo) Add(ctx context.Context, e domain.OutboxEvent) error {
+	const op = "outboxRepo.Add"
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("%s: json.Marshal: %w", op, err)
+	}
+
+	_, err = conn(ctx, r.pool).Exec(ctx, `
+INSERT INTO schema_.outbox (id, event_name, event_key, payload)
+VALUES ($1, $2, $3, $4)`, uuid.New(), e.EventName(), e.OutboxKey(), payload)
+	if err != nil {
+		return fmt.Errorf("%s: Exec: %w", op, err)
+	}
+	return nil
+}
+
+// Unpublished locks the oldest unpublished messages until the transaction of ctx ends, other instances
+// skip them
+func (r *outboxRepo) Unpublished(ctx context.Context, limit int) ([]domain.OutboxMessage, error) {
+	const op = "outboxRepo.Unpublished"
+
+	rows, err := conn(ctx, r.pool).Query(ctx, `
+SELECT id, event_name, payload FROM schema_.outbox
+WHERE published_at IS NULL
+ORDER BY created_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: Query: %w", op, err)
+	}
+
+	msgs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.OutboxMessage, error) {
+		msg := domain.OutboxMessage{}
+		err := row.Scan(&msg.ID, &msg.Name, &msg.Payload)
+		return msg, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: pgx.CollectRows: %w", op, err)
+	}
+	return msgs, nil
+}
+
+func (r *outboxRepo) MarkPublished(ctx context.Context, ids []uuid.UUID) error {
+	const op = "outboxRepo.MarkPublished"
+
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+UPDATE schema_.outbox SET published_at = now() WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return fmt.Errorf("%s: Exec: %w", op, err)
+	}
+	return nil
+}
+
+// Purge deletes messages published before the time
+func (r *outboxRepo) Purge(ctx context.Context, before time.Time) (int64, error) {
+	const op = "outboxRepo.Purge"
+
+	tag, err := r.pool.Exec(ctx, `DELETE FROM schema_.outbox WHERE published_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("%s: r.pool.Exec: %w", op, err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"go-clean-template/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type policyRepo struct {
+	pool DB
+}
+
+func NewPolicyRepo(pool DB) *policyRepo {
+	return &policyRepo{
+		pool,
+	}
+}
+
+func (r *policyRepo) Load(ctx context.Context) (domain.Policy, error) {
+	const op = "policyRepo.Load"
+
+	rows, err := r.pool.Query(ctx, `SELECT name, permissions FROM schema_.rbac_roles ORDER BY name`)
+	if err != nil {
+		return domain.Policy{}, fmt.Errorf("%s: roles: r.pool.Query: %w", op, err)
+	}
+	roles, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.Role, error) {
+		role := domain.Role{}
+		err := row.Scan(&role.Name, &role.Permissions)
+		return role, err
+	})
+	if err != nil {
+		return domain.Policy{}, fmt.Errorf("%s: roles: pgx.CollectRows: %w", op, err)
+	}
+
+	rows, err = r.pool.Query(ctx, `
+SELECT subject, array_agg(role ORDER BY role)
+FROM schema_.rbac_bindings
+GROUP BY subject
+ORDER BY subject`)
+	if err != nil {
+		return domain.Policy{}, fmt.Errorf("%s: bindings: r.pool.Query: %w", op, err)
+	}
+	bindings, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.RoleBinding, error) {
+		b := domain.RoleBinding{}
+		err := row.Scan(&b.Subject, &b.Roles)
+		return b, err
+	})
+	if err != nil {
+		return domain.Policy{}, fmt.Errorf("%s: bindings: pgx.CollectRows: %w", op, err)
+	}
+
+	rows, err = r.pool.Query(ctx, `SELECT method, path, permission FROM schema_.rbac_routes ORDER BY path, method`)
+	if err != nil {
+		return domain.Policy{}, fmt.Errorf("%s: routes: r.pool.Query: %w", op, err)
+	}
+	routes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.RoutePermission, error) {
+		rp := domain.RoutePermission{}
+		err := row.Scan(&rp.Method, &rp.Path, &rp.Permission)
+		return rp, err
+	})
+	if err != nil {
+		return domain.Policy{}, fmt.Errorf("%s: routes: pgx.CollectRows: %w", op, err)
+	}
+
+	return domain.Policy{Roles: roles, Bindings: bindings, Routes: routes}, nil
+}
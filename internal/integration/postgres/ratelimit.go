@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"go-clean-template/pkg/ratelimit"
+)
+
+// refilled is the token count of the stored bucket refilled up to now, $2 is the burst and $3 the rate
+const refilled = `LEAST($2, rate_limits.tokens + EXTRACT(EPOCH FROM now() - rate_limits.updated_at)::float8 * $3)`
+
+type rateLimitRepo struct {
+	pool DB
+}
+
+func NewRateLimitRepo(pool DB) *rateLimitRepo {
+	return &rateLimitRepo{
+		pool,
+	}
+}
+
+// Take refills and takes from the bucket in one statement, the row lock serializes replicas.
+// The clock of the database is used so replicas don't have to agree on time.
+func (r *rateLimitRepo) Take(ctx context.Context, key string, l ratelimit.Limit) (ratelimit.Result, error) {
+	const op = "rateLimitRepo.Take"
+
+	var tokens float64
+	var allowed bool
+	err := r.pool.QueryRow(ctx, `
+INSERT INTO schema_.rate_limits (key, tokens, allowed, updated_at, expires_at)
+VALUES ($1, $2::float8 - 1, true, now(), now() + make_interval(secs => $4))
+ON CONFLICT (key) DO UPDATE
+SET tokens = CASE WHEN `+refilled+` >= 1 THEN `+refilled+` - 1 ELSE `+refilled+` END,
+    allowed = `+refilled+` >= 1,
+    updated_at = now(),
+    expires_at = EXCLUDED.expires_at
+RETURNING tokens, allowed`,
+		key, float64(l.Burst), l.Rate, l.Window().Seconds()).Scan(&tokens, &allowed)
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("%s: row.Scan: %w", op, err)
+	}
+	return ratelimit.NewResult(l, tokens, allowed), nil
+}
+
+// Purge deletes buckets refilled since their last request
+func (r *rateLimitRepo) Purge(ctx context.Context) (int64, error) {
+	const op = "rateLimitRepo.Purge"
+
+	tag, err := r.pool.Exec(ctx, `DELETE FROM schema_.rate_limits WHERE expires_at < now()`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: r.pool.Exec: %w", op, err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/logger"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	relayRetry = time.Second
+	// maxNotifyPayload is the payload limit of NOTIFY in the default build of Postgres
+	maxNotifyPayload = 8000
+)
+
+type relayMessage struct {
+	Name    string          `json:"name"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// eventRelay shares events between instances with LISTEN/NOTIFY. Notifications sent in a transaction
+// are delivered on commit. Instances that are reconnecting miss the events sent in the meantime.
+type eventRelay struct {
+	pool     *pgxpool.Pool
+	db       DB
+	channel  string
+	mu       sync.RWMutex
+	handlers []func(name string, payload []byte)
+	lg       logger.Logger
+}
+
+// NewEventRelay sends notifications with db and listens on a connection of pool, Run starts listening
+func NewEventRelay(pool *pgxpool.Pool, db DB, channel string, lg logger.Logger) *eventRelay {
+	return &eventRelay{
+		pool:    pool,
+		db:      db,
+		channel: channel,
+		lg:      lg,
+	}
+}
+
+// Relay notifies every instance with the JSON of the event, in the transaction of ctx if there is one
+func (r *eventRelay) Relay(ctx context.Context, e domain.Event) error {
+	const op = "eventRelay.Relay"
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("%s: json.Marshal: %w", op, err)
+	}
+	msg, err := json.Marshal(relayMessage{Name: e.EventName(), Payload: payload})
+	if err != nil {
+		return fmt.Errorf("%s: json.Marshal: %w", op, err)
+	}
+	if len(msg) > maxNotifyPayload {
+		return fmt.Errorf("%s: %s of %d bytes: %w", op, e.EventName(), len(msg), domain.ErrEventTooLarge)
+	}
+
+	_, err = conn(ctx, r.db).Exec(ctx, `SELECT pg_notify($1, $2)`, r.channel, string(msg))
+	if err != nil {
+		return fmt.Errorf("%s: Exec: %w", op, err)
+	}
+	return nil
+}
+
+func (r *eventRelay) Listen(h func(name string, payload []byte)) {
+	r.mu.Lock()
+	r.handlers = append(r.handlers, h)
+	r.mu.Unlock()
+}
+
+// Run listens until ctx is done, the connection is opened again after errors
+func (r *eventRelay) Run(ctx context.Context) {
+	for {
+		err := r.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		r.lg.Error(fmt.Errorf("eventRelay.Run: %w", err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(relayRetry):
+		}
+	}
+}
+
+func (r *eventRelay) listen(ctx context.Context) error {
+	c, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("pool.Acquire: %w", err)
+	}
+	// a listening connection must not go back to the pool
+	pc := c.Hijack()
+	defer pc.Close(context.WithoutCancel(ctx))
+
+	_, err = pc.Exec(ctx, "LISTEN "+pgx.Identifier{r.channel}.Sanitize())
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	for {
+		n, err := pc.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("WaitForNotification: %w", err)
+		}
+		r.dispatch(n.Payload)
+	}
+}
+
+func (r *eventRelay) dispatch(payload string) {
+	var msg relayMessage
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		r.lg.Error(fmt.Errorf("eventRelay.dispatch: %w", err))
+		return
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, h := range r.handlers {
+		h(msg.Name, msg.Payload)
+	}
+}
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"go-clean-template/internal/domain"
+)
+
+type txKey struct{}
+
+type transactor struct {
+	pool DB
+}
+
+func NewTransactor(pool DB) *transactor {
+	return &transactor{
+		pool,
+	}
+}
+
+func (t *transactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	const op = "transactor.InTx"
+
+	if _, ok := ctx.Value(txKey{}).(DB); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: t.pool.Begin: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	hooks := &domain.TxHooks{}
+	err = fn(domain.ContextWithTxHooks(context.WithValue(ctx, txKey{}, DB(tx)), hooks))
+	if err != nil {
+		return err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: tx.Commit: %w", op, err)
+	}
+	hooks.Run(ctx)
+	return nil
+}
+
+// conn returns the transaction of ctx or pool, repositories that write together with the domain use it
+func conn(ctx context.Context, pool DB) DB {
+	if tx, ok := ctx.Value(txKey{}).(DB); ok {
+		return tx
+	}
+	return pool
+}
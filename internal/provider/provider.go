@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"go-clean-template/config"
 
@@ -10,18 +11,37 @@ import (
 	"go-clean-template/internal/integration/postgres"
 
 	"go-clean-template/internal/service"
+	"go-clean-template/pkg/idempotency"
 	"go-clean-template/pkg/logger"
 	"go-clean-template/pkg/monitoring"
+	"go-clean-template/pkg/ratelimit"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose"
 )
 
+const closeTimeout = 10 * time.Second
+
 type provider struct {
-	service domain.Service
-	cfg     *config.Config
-	mon     monitoring.Monitoring
-	lg      logger.Logger
+	events    domain.EventBus
+	relay     domain.EventRelay
+	stopRelay context.CancelFunc
+	service   domain.Service
+	audit     domain.AuditService
+	auth      domain.AuthService
+	policy    domain.PolicyService
+	jobs      domain.JobService
+	backfill  domain.BackfillService
+	idem      idempotency.Repository
+	limits    ratelimit.Store
+	cfg       atomic.Pointer[config.Config]
+	reloadMu  sync.Mutex
+	onReload  []func(cfg *config.Config)
+	mon       monitoring.Monitoring
+	lg        logger.Logger
 }
 
 func New(cfg *config.Config, mon monitoring.Monitoring, lg logger.Logger) (*provider, error) {
@@ -45,22 +65,186 @@ func New(cfg *config.Config, mon monitoring.Monitoring, lg logger.Logger) (*prov
 		return nil, fmt.Errorf("failed to apply migrations: %w", err)
 	}
 	lg.Info("connected to database")
-	service := service.NewService(lg)
+	var conn postgres.DB = pool
+	if cfg.DB.AnnotateQueries {
+		conn = postgres.WithRequestID(pool)
+	}
+	outboxRepo := postgres.NewOutboxRepo(conn)
+	events := domain.NewEventBus(outboxRepo, mon, lg)
+	relay := postgres.NewEventRelay(pool, conn, schema+"_events", lg)
+	for _, name := range []string{domain.EventJobRunStarted, domain.EventJobRunFinished} {
+		events.Subscribe(name, "relay", domain.DeliverySync, relay.Relay)
+	}
+	// outbox events reach the other instances through the outbox, so they are not lost with the process
+	outbox := service.NewOutboxRelay(outboxRepo, postgres.NewTransactor(conn), relay, cfg.Outbox, mon, lg)
+	svc := service.NewService(events, postgres.NewTransactor(conn), lg)
+	audit := service.NewAuditService(postgres.NewAuditRepo(conn), mon, lg)
+	var policyRepo service.PolicyRepository
+	if cfg.Policy.Postgres {
+		policyRepo = postgres.NewPolicyRepo(conn)
+	}
+	policy, err := service.NewPolicyService(context.Background(), cfg.Policy, policyRepo, mon, lg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create policy service: %w", err)
+	}
+	jobs := service.NewJobService(postgres.NewJobRunRepo(conn), audit, policy, events, cfg.InstanceID, mon, lg)
+	jobs.Register(domain.JobPersist, service.PersistJob(svc))
+	err = jobs.Recover(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover job runs: %w", err)
+	}
+	backfill := service.NewBackfillService(postgres.NewBackfillRepo(conn), svc, jobs, cfg.Backfill, cfg.InstanceID,
+		mon, lg)
+	err = backfill.Recover(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover backfill runs: %w", err)
+	}
+	var auth domain.AuthService
+	if cfg.HTTP.Auth.Enabled {
+		var keys service.APIKeyRepository
+		if cfg.HTTP.Auth.APIKeys.Postgres {
+			keys = postgres.NewAPIKeyRepo(conn)
+		}
+		auth, err = service.NewAuthService(cfg.HTTP.Auth, keys, mon, lg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create auth service: %w", err)
+		}
+	}
+	idem := postgres.NewIdempotencyRepo(conn)
+	jobs.Register(domain.JobIdempotencyPurge, service.IdempotencyPurgeJob(idem, lg))
+	limitRepo := postgres.NewRateLimitRepo(conn)
+	jobs.Register(domain.JobRateLimitPurge, service.RateLimitPurgeJob(limitRepo, lg))
+	jobs.Register(domain.JobOutboxPurge, service.OutboxPurgeJob(outboxRepo, cfg.Outbox.Retention, lg))
+	var limits ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.HTTP.RateLimit.Store == "postgres" {
+		limits = limitRepo
+	}
 
-	return &provider{
-		service,
-		cfg,
-		mon,
-		lg,
-	}, nil
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	go relay.Run(relayCtx)
+	go outbox.Run(relayCtx)
+
+	p := &provider{
+		events:    events,
+		relay:     relay,
+		stopRelay: stopRelay,
+		service:   svc,
+		audit:     audit,
+		auth:      auth,
+		policy:    policy,
+		jobs:      jobs,
+		backfill:  backfill,
+		idem:      idem,
+		limits:    limits,
+		mon:       mon,
+		lg:        lg,
+	}
+	p.cfg.Store(cfg)
+	p.OnConfigReload(func(cfg *config.Config) {
+		policy.Apply(cfg.Policy)
+	})
+	return p, nil
+}
+
+func (p *provider) GetEventBus() domain.EventBus {
+	return p.events
+}
+
+func (p *provider) GetEventRelay() domain.EventRelay {
+	return p.relay
 }
 
 func (p *provider) GetService() domain.Service {
 	return p.service
 }
 
+func (p *provider) GetAuditService() domain.AuditService {
+	return p.audit
+}
+
+// GetAuthService returns nil if authentication is disabled
+func (p *provider) GetAuthService() domain.AuthService {
+	return p.auth
+}
+
+func (p *provider) GetPolicyService() domain.PolicyService {
+	return p.policy
+}
+
+func (p *provider) GetJobService() domain.JobService {
+	return p.jobs
+}
+
+func (p *provider) GetBackfillService() domain.BackfillService {
+	return p.backfill
+}
+
+func (p *provider) GetIdempotencyRepository() idempotency.Repository {
+	return p.idem
+}
+
+func (p *provider) GetRateLimitStore() ratelimit.Store {
+	return p.limits
+}
+
+func (p *provider) GetEnv() string {
+	return p.GetConfig().Env
+}
+
 func (p *provider) GetAppVersion() string {
-	return p.cfg.AppVersion
+	return p.GetConfig().AppVersion
+}
+
+func (p *provider) GetConfig() *config.Config {
+	return p.cfg.Load()
+}
+
+// OnConfigReload subscribes fn to reloaded configs, fn must apply only the settings that can change at runtime
+func (p *provider) OnConfigReload(fn func(cfg *config.Config)) {
+	p.reloadMu.Lock()
+	p.onReload = append(p.onReload, fn)
+	p.reloadMu.Unlock()
+}
+
+// ReloadConfig reads the config file again and passes it to subscribers
+func (p *provider) ReloadConfig(ctx context.Context) (*config.Config, error) {
+	const op = "provider.ReloadConfig"
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	old := p.GetConfig()
+	cfg, err := config.Load(old.FileName)
+	if err == nil {
+		cfg.InstanceID = old.InstanceID
+		p.cfg.Store(cfg)
+		for _, fn := range p.onReload {
+			fn(cfg)
+		}
+		p.lg.WithContext(ctx).Info(fmt.Sprintf("config %s reloaded", cfg.FileName))
+	}
+
+	entry := domain.AuditEntry{
+		Action:     domain.AuditActionConfigReload,
+		ResourceID: old.FileName,
+		RequestID:  logger.RequestIDFromContext(ctx),
+		Outcome:    domain.AuditOutcomeSuccess,
+	}
+	entry.BeforeHash, _ = domain.StateHash(json.RawMessage(old.ConfigString))
+	entry.AfterHash = entry.BeforeHash
+	if err != nil {
+		entry.Outcome = domain.AuditOutcomeFailure
+		entry.Error = err.Error()
+	} else {
+		entry.AfterHash, _ = domain.StateHash(json.RawMessage(cfg.ConfigString))
+	}
+	if auditErr := p.audit.Record(ctx, entry); auditErr != nil {
+		p.lg.WithContext(ctx).Error(fmt.Errorf("%s: %w", op, auditErr))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return cfg, nil
 }
 
 func (p *provider) GetMonitoring() monitoring.Monitoring {
@@ -72,5 +256,23 @@ func (p *provider) GetLogger() logger.Logger {
 }
 
 func (p *provider) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+
+	// background backfill runs save their progress before the events they publish are drained
+	err := p.backfill.Close(ctx)
+	if err != nil {
+		p.lg.Error("backfill.Close:", err)
+	}
 
+	err = p.jobs.Close(ctx)
+	if err != nil {
+		p.lg.Error("jobs.Close:", err)
+	}
+
+	err = p.events.Close(ctx)
+	if err != nil {
+		p.lg.Error("events.Close:", err)
+	}
+	p.stopRelay()
 }
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/listquery"
+	"go-clean-template/pkg/logger"
+	"go-clean-template/pkg/monitoring"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const auditMetrics = "audit"
+
+type AuditRepository interface {
+	Insert(ctx context.Context, e domain.AuditEntry) error
+	List(ctx context.Context, q listquery.Query) ([]domain.AuditEntry, error)
+}
+
+type auditService struct {
+	repo AuditRepository
+	mon  monitoring.Monitoring
+	lg   logger.Logger
+}
+
+func NewAuditService(repo AuditRepository, mon monitoring.Monitoring, lg logger.Logger) *auditService {
+	mon.Register(auditMetrics)
+	return &auditService{
+		repo,
+		mon,
+		lg,
+	}
+}
+
+func (s *auditService) Record(ctx context.Context, e domain.AuditEntry) error {
+	const op = "auditService.Record"
+
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	if e.Actor == "" {
+		e.Actor = domain.ActorFromContext(ctx)
+	}
+
+	// an entry must be written even if the caller has gone
+	err := s.repo.Insert(context.WithoutCancel(ctx), e)
+	s.mon.Count(auditMetrics, e.Action, err != nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *auditService) List(ctx context.Context, q listquery.Query) ([]domain.AuditEntry, error) {
+	const op = "auditService.List"
+
+	entries, err := s.repo.List(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-clean-template/config"
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/jwks"
+	"go-clean-template/pkg/logger"
+	"go-clean-template/pkg/monitoring"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	authMetrics       = "auth"
+	defaultScopeClaim = "scope"
+)
+
+type APIKeyRepository interface {
+	FindByHash(ctx context.Context, hash string) (domain.APIKey, error)
+}
+
+type KeySet interface {
+	Key(kid string) (jwks.Key, error)
+}
+
+type authService struct {
+	cfg    config.Auth
+	parser *jwt.Parser
+	keys   KeySet
+	repo   APIKeyRepository
+	mon    monitoring.Monitoring
+	lg     logger.Logger
+}
+
+// NewAuthService checks the key material of enabled methods, repo may be nil if keys are not stored in Postgres
+func NewAuthService(cfg config.Auth, repo APIKeyRepository, mon monitoring.Monitoring,
+	lg logger.Logger) (*authService, error) {
+	const op = "service.NewAuthService"
+	mon.Register(authMetrics)
+
+	s := &authService{
+		cfg:  cfg,
+		repo: repo,
+		mon:  mon,
+		lg:   lg,
+	}
+	if cfg.JWT.ScopeClaim == "" {
+		s.cfg.JWT.ScopeClaim = defaultScopeClaim
+	}
+
+	if cfg.JWT.Enabled {
+		if len(cfg.JWT.Algorithms) == 0 {
+			return nil, fmt.Errorf("%s: jwt algorithms are not set", op)
+		}
+		for _, alg := range cfg.JWT.Algorithms {
+			if strings.HasPrefix(alg, "HS") && cfg.JWT.HMACSecret == "" {
+				return nil, fmt.Errorf("%s: %s needs hmac-secret", op, alg)
+			}
+		}
+		if cfg.JWT.JWKSFile != "" {
+			keys, err := jwks.New(cfg.JWT.JWKSFile, cfg.JWT.JWKSRefresh)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			s.keys = keys
+		}
+
+		opts := []jwt.ParserOption{
+			jwt.WithValidMethods(cfg.JWT.Algorithms),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(cfg.JWT.Leeway),
+		}
+		if cfg.JWT.Issuer != "" {
+			opts = append(opts, jwt.WithIssuer(cfg.JWT.Issuer))
+		}
+		if cfg.JWT.Audience != "" {
+			opts = append(opts, jwt.WithAudience(cfg.JWT.Audience))
+		}
+		s.parser = jwt.NewParser(opts...)
+	}
+
+	return s, nil
+}
+
+func (s *authService) AuthenticateBearer(ctx context.Context, token string) (domain.Principal, error) {
+	if !s.cfg.JWT.Enabled {
+		return domain.Principal{}, domain.NewUnauthorizedError(domain.CodeAuthInvalid, nil)
+	}
+
+	var kid string
+	claims := jwt.MapClaims{}
+	_, err := s.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+			return []byte(s.cfg.JWT.HMACSecret), nil
+		}
+		if s.keys == nil {
+			return nil, errors.New("jwks-file is not set")
+		}
+		kid, _ = t.Header["kid"].(string)
+		k, err := s.keys.Key(kid)
+		if err != nil {
+			return nil, err
+		}
+		if k.Alg != "" && k.Alg != t.Method.Alg() {
+			return nil, fmt.Errorf("key %s is for %s", kid, k.Alg)
+		}
+		return k.Public, nil
+	})
+	if err != nil {
+		s.mon.Count(authMetrics, domain.AuthMethodJWT, true)
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return domain.Principal{}, domain.NewUnauthorizedError(domain.CodeAuthTokenExpired, nil)
+		}
+		s.lg.WithContext(ctx).Debug(fmt.Sprintf("jwt rejected: %v", err))
+		return domain.Principal{}, domain.NewUnauthorizedError(domain.CodeAuthInvalid, nil)
+	}
+
+	sub, _ := claims.GetSubject()
+	if sub == "" {
+		s.mon.Count(authMetrics, domain.AuthMethodJWT, true)
+		return domain.Principal{}, domain.NewUnauthorizedError(domain.CodeAuthInvalid, nil)
+	}
+	s.mon.Count(authMetrics, domain.AuthMethodJWT, false)
+
+	return domain.Principal{
+		Subject: sub,
+		Method:  domain.AuthMethodJWT,
+		Scopes:  scopesClaim(claims[s.cfg.JWT.ScopeClaim]),
+		KeyID:   kid,
+	}, nil
+}
+
+func (s *authService) AuthenticateAPIKey(ctx context.Context, key string) (domain.Principal, error) {
+	const op = "authService.AuthenticateAPIKey"
+
+	sum := sha256.Sum256([]byte(key))
+	hash := hex.EncodeToString(sum[:])
+
+	for _, k := range s.cfg.APIKeys.Static {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(strings.ToLower(k.Hash))) == 1 {
+			s.mon.Count(authMetrics, domain.AuthMethodAPIKey, false)
+			return domain.Principal{Subject: k.Subject, Method: domain.AuthMethodAPIKey, Scopes: k.Scopes,
+				KeyID: k.ID}, nil
+		}
+	}
+
+	if s.repo == nil {
+		s.mon.Count(authMetrics, domain.AuthMethodAPIKey, true)
+		return domain.Principal{}, domain.NewUnauthorizedError(domain.CodeAuthInvalid, nil)
+	}
+	k, err := s.repo.FindByHash(ctx, hash)
+	var notFound domain.NotFoundError
+	if errors.As(err, &notFound) {
+		s.mon.Count(authMetrics, domain.AuthMethodAPIKey, true)
+		return domain.Principal{}, domain.NewUnauthorizedError(domain.CodeAuthInvalid, nil)
+	}
+	if err != nil {
+		return domain.Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	if k.RevokedAt != nil || (k.ExpiresAt != nil && k.ExpiresAt.Before(now)) {
+		s.mon.Count(authMetrics, domain.AuthMethodAPIKey, true)
+		return domain.Principal{}, domain.NewUnauthorizedError(domain.CodeAuthAPIKeyRevoked, domain.Params{"id": k.ID})
+	}
+	s.mon.Count(authMetrics, domain.AuthMethodAPIKey, false)
+
+	return domain.Principal{Subject: k.Subject, Method: domain.AuthMethodAPIKey, Scopes: k.Scopes, KeyID: k.ID}, nil
+}
+
+func scopesClaim(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return strings.Fields(t)
+	case []any:
+		scopes := make([]string, 0, len(t))
+		for _, s := range t {
+			if str, ok := s.(string); ok {
+				scopes = append(scopes, str)
+			}
+		}
+		return scopes
+	}
+	return []string{}
+}
@@ -0,0 +1,415 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-clean-template/config"
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/logger"
+	"go-clean-template/pkg/monitoring"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	backfillMetrics            = "backfill"
+	defaultBackfillConcurrency = 4
+	defaultBackfillMaxDays     = 366
+	defaultBackfillLockTTL     = time.Minute
+)
+
+type BackfillRepository interface {
+	CreateRun(ctx context.Context, run domain.BackfillRun) error
+	FindUnfinishedRun(ctx context.Context, from, to string) (domain.BackfillRun, error)
+	GetRun(ctx context.Context, id uuid.UUID) (domain.BackfillRun, error)
+	ListRuns(ctx context.Context, limit int) ([]domain.BackfillRun, error)
+	ListDays(ctx context.Context, id uuid.UUID) ([]domain.BackfillDay, error)
+	PendingDays(ctx context.Context, id uuid.UUID) ([]string, error)
+	SetRunStatus(ctx context.Context, id uuid.UUID, status domain.BackfillStatus) error
+	LockRun(ctx context.Context, id, owner uuid.UUID, ttl time.Duration) (bool, error)
+	UnlockRun(ctx context.Context, id, owner uuid.UUID) error
+	ResetInterrupted(ctx context.Context) (int64, error)
+	ResetRunningDays(ctx context.Context, id uuid.UUID) error
+	StartDay(ctx context.Context, id uuid.UUID, dt string) error
+	FinishDay(ctx context.Context, id uuid.UUID, dt string, status domain.BackfillStatus, errText string) error
+}
+
+type backfillService struct {
+	repo    BackfillRepository
+	service domain.Service
+	jobs    domain.JobService
+	cfg     config.Backfill
+	// owner of the leases of runs taken by this instance
+	owner  uuid.UUID
+	active sync.Map
+	// ctx of background runs is cancelled by Close, wg waits for them
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	wg     sync.WaitGroup
+	mon    monitoring.Monitoring
+	lg     logger.Logger
+}
+
+func NewBackfillService(repo BackfillRepository, service domain.Service, jobs domain.JobService,
+	cfg config.Backfill, owner uuid.UUID, mon monitoring.Monitoring, lg logger.Logger) *backfillService {
+	mon.Register(backfillMetrics)
+	if cfg.LockTTL <= 0 {
+		cfg.LockTTL = defaultBackfillLockTTL
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &backfillService{
+		repo:    repo,
+		service: service,
+		jobs:    jobs,
+		cfg:     cfg,
+		owner:   owner,
+		ctx:     ctx,
+		cancel:  cancel,
+		mon:     mon,
+		lg:      lg,
+	}
+}
+
+// Recover stops runs that were left running by a process that died, their days can be resumed
+func (s *backfillService) Recover(ctx context.Context) error {
+	const op = "backfillService.Recover"
+
+	n, err := s.repo.ResetInterrupted(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n > 0 {
+		s.lg.Info(fmt.Sprintf("%s: %d interrupted days put back to pending", op, n))
+	}
+	return nil
+}
+
+func (s *backfillService) Create(ctx context.Context, from, to string) (domain.BackfillRun, error) {
+	const op = "backfillService.Create"
+
+	err := s.validateRange(from, to)
+	if err != nil {
+		return domain.BackfillRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	run, err := s.repo.FindUnfinishedRun(ctx, from, to)
+	if err == nil {
+		s.lg.Info(fmt.Sprintf("%s: reusing unfinished run %s for %s..%s", op, run.ID, from, to))
+		return run, nil
+	}
+	var notFound domain.NotFoundError
+	if !errors.As(err, &notFound) {
+		return domain.BackfillRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	run = domain.BackfillRun{
+		ID:       uuid.New(),
+		DateFrom: from,
+		DateTo:   to,
+		Status:   domain.BackfillStatusPending,
+	}
+	err = s.repo.CreateRun(ctx, run)
+	if err != nil {
+		return domain.BackfillRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	run, err = s.repo.GetRun(ctx, run.ID)
+	if err != nil {
+		return domain.BackfillRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return run, nil
+}
+
+// Start runs the backfill in the background. The run outlives ctx of the caller and is stopped by Close,
+// its days are then put back to pending and the run can be resumed.
+func (s *backfillService) Start(ctx context.Context, id uuid.UUID) error {
+	const op = "backfillService.Start"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx.Err() != nil {
+		return fmt.Errorf("%s: %w", op, domain.NewUnavailableError(domain.CodeStopping, nil))
+	}
+
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(s.ctx, cancel)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer stop()
+		defer cancel()
+		_, err := s.Run(runCtx, id)
+		if err != nil {
+			s.lg.WithContext(runCtx).Error(fmt.Errorf("%s: run %s: %w", op, id, err))
+		}
+	}()
+	return nil
+}
+
+// Close stops the background runs and waits for them to save their progress
+func (s *backfillService) Close(ctx context.Context) error {
+	s.mu.Lock()
+	s.cancel()
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("backfillService.Close: %w", ctx.Err())
+	}
+}
+
+func (s *backfillService) Run(ctx context.Context, id uuid.UUID) (domain.BackfillRun, error) {
+	const op = "backfillService.Run"
+
+	inProgress := domain.NewAlreadyProcessedError(domain.CodeBackfillInProgress, domain.Params{"id": id.String()})
+	if _, loaded := s.active.LoadOrStore(id, struct{}{}); loaded {
+		return domain.BackfillRun{}, fmt.Errorf("%s: %w", op, inProgress)
+	}
+	defer s.active.Delete(id)
+
+	run, err := s.repo.GetRun(ctx, id)
+	if err != nil {
+		return domain.BackfillRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// the lease keeps other instances off the run
+	locked, err := s.repo.LockRun(ctx, id, s.owner, s.cfg.LockTTL)
+	if err != nil {
+		return domain.BackfillRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !locked {
+		return domain.BackfillRun{}, fmt.Errorf("%s: %w", op, inProgress)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	renewCtx, stopRenew := context.WithCancel(ctx)
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		s.renewLease(renewCtx, cancel, id)
+	}()
+	defer func() {
+		// the lease is released after the last renewal
+		stopRenew()
+		<-renewed
+		if err := s.repo.UnlockRun(context.WithoutCancel(ctx), id, s.owner); err != nil {
+			s.lg.WithContext(ctx).Error(fmt.Errorf("%s: %w", op, err))
+		}
+	}()
+	err = s.repo.ResetRunningDays(ctx, id)
+	if err != nil {
+		return domain.BackfillRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	params := map[string]string{"run_id": id.String(), "from": run.DateFrom, "to": run.DateTo}
+	_, err = s.jobs.Track(ctx, domain.JobBackfill, domain.JobTriggerManual, params, func(ctx context.Context) error {
+		var runErr error
+		run, runErr = s.run(ctx, id)
+		return runErr
+	})
+	if err != nil {
+		return run, fmt.Errorf("%s: %w", op, err)
+	}
+	return run, nil
+}
+
+func (s *backfillService) run(ctx context.Context, id uuid.UUID) (domain.BackfillRun, error) {
+	const op = "backfillService.run"
+
+	days, err := s.repo.PendingDays(ctx, id)
+	if err != nil {
+		return domain.BackfillRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.repo.SetRunStatus(ctx, id, domain.BackfillStatusRunning)
+	if err != nil {
+		return domain.BackfillRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tn := time.Now()
+	s.lg.Info(fmt.Sprintf("%s: run %s started, %d days left", op, id, len(days)))
+
+	var done, failed atomic.Int64
+	eg := errgroup.Group{}
+	eg.SetLimit(s.concurrency())
+	for _, dt := range days {
+		eg.Go(func() error {
+			if ctx.Err() != nil {
+				return nil
+			}
+			switch s.persistDay(ctx, id, dt) {
+			case domain.BackfillStatusDone:
+				done.Add(1)
+			case domain.BackfillStatusFailed:
+				failed.Add(1)
+			default:
+				return nil
+			}
+			s.lg.Info(fmt.Sprintf("%s: run %s progress %d/%d, %d failed",
+				op, id, done.Load()+failed.Load(), len(days), failed.Load()))
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	status := domain.BackfillStatusDone
+	switch {
+	case ctx.Err() != nil:
+		status = domain.BackfillStatusStopped
+	case failed.Load() > 0:
+		status = domain.BackfillStatusFailed
+	}
+
+	// status must be saved even if the run was stopped by ctx
+	statusCtx := context.WithoutCancel(ctx)
+	err = s.repo.SetRunStatus(statusCtx, id, status)
+	if err != nil {
+		return domain.BackfillRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	run, err := s.repo.GetRun(statusCtx, id)
+	if err != nil {
+		return domain.BackfillRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+	s.lg.Info(fmt.Sprintf("%s: run %s %s in %v: %d/%d days done, %d failed",
+		op, id, run.Status, time.Since(tn), run.Done, run.Total, run.Failed))
+
+	switch run.Status {
+	case domain.BackfillStatusStopped:
+		return run, fmt.Errorf("%s: run %s stopped: %w", op, id, ctx.Err())
+	case domain.BackfillStatusFailed:
+		return run, fmt.Errorf("%s: run %s: %d of %d days failed", op, id, run.Failed, run.Total)
+	}
+	return run, nil
+}
+
+// renewLease renews the lease of the run until ctx is done, the run is stopped by stopRun if the lease is lost
+func (s *backfillService) renewLease(ctx context.Context, stopRun context.CancelFunc, id uuid.UUID) {
+	ticker := time.NewTicker(s.cfg.LockTTL / 3) //nolint:mnd //renewed before it expires
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			locked, err := s.repo.LockRun(ctx, id, s.owner, s.cfg.LockTTL)
+			if err != nil && ctx.Err() == nil {
+				s.lg.WithContext(ctx).Error(fmt.Errorf("backfillService.renewLease: run %s: %w", id, err))
+				continue
+			}
+			if !locked && ctx.Err() == nil {
+				s.lg.WithContext(ctx).Error(fmt.Errorf("backfillService.renewLease: run %s: lease lost", id))
+				stopRun()
+				return
+			}
+		}
+	}
+}
+
+func (s *backfillService) Get(ctx context.Context, id uuid.UUID) (domain.BackfillRun, []domain.BackfillDay, error) {
+	const op = "backfillService.Get"
+
+	run, err := s.repo.GetRun(ctx, id)
+	if err != nil {
+		return domain.BackfillRun{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	days, err := s.repo.ListDays(ctx, id)
+	if err != nil {
+		return domain.BackfillRun{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return run, days, nil
+}
+
+func (s *backfillService) List(ctx context.Context, limit int) ([]domain.BackfillRun, error) {
+	const op = "backfillService.List"
+
+	runs, err := s.repo.ListRuns(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return runs, nil
+}
+
+// persistDay returns the final status of the day. Days interrupted by ctx are put back to pending
+func (s *backfillService) persistDay(ctx context.Context, id uuid.UUID, dt string) domain.BackfillStatus {
+	const op = "backfillService.persistDay"
+	statusCtx := context.WithoutCancel(ctx)
+
+	// a day that could not be started is recorded as failed like a day that could not be persisted
+	err := s.repo.StartDay(ctx, id, dt)
+	if err == nil {
+		tn := time.Now()
+		err = s.service.Persist(ctx, dt)
+		s.mon.Observe(backfillMetrics, "persist", time.Since(tn).Seconds())
+		s.mon.Count(backfillMetrics, "persist", err != nil)
+	}
+
+	status, errText := domain.BackfillStatusDone, ""
+	switch {
+	case err != nil && ctx.Err() != nil:
+		status = domain.BackfillStatusPending
+	case err != nil:
+		status, errText = domain.BackfillStatusFailed, err.Error()
+		s.lg.Error(fmt.Errorf("%s: run %s: %s: %w", op, id, dt, err))
+	}
+
+	err = s.repo.FinishDay(statusCtx, id, dt, status, errText)
+	if err != nil {
+		s.lg.Error(fmt.Errorf("%s: %s: %w", op, dt, err))
+		return domain.BackfillStatusFailed
+	}
+	return status
+}
+
+func (s *backfillService) validateRange(from, to string) error {
+	dtFrom, err := time.Parse(time.DateOnly, from)
+	if err != nil {
+		return domain.NewValidationError(
+			domain.NewFieldError("from", domain.CodeFieldInvalidDate, domain.Params{"param": time.DateOnly}))
+	}
+	dtTo, err := time.Parse(time.DateOnly, to)
+	if err != nil {
+		return domain.NewValidationError(
+			domain.NewFieldError("to", domain.CodeFieldInvalidDate, domain.Params{"param": time.DateOnly}))
+	}
+	if dtTo.Before(dtFrom) {
+		return domain.NewValidationError(
+			domain.NewFieldError("to", domain.CodeFieldInvalidRange, domain.Params{"param": "from"}))
+	}
+
+	maxDays := s.cfg.MaxDays
+	if maxDays <= 0 {
+		maxDays = defaultBackfillMaxDays
+	}
+	if days := int(dtTo.Sub(dtFrom).Hours()/24) + 1; days > maxDays { //nolint:mnd //hours per day
+		params := domain.Params{"max": strconv.Itoa(maxDays)}
+		return domain.ValidationError{
+			Message: domain.CodeBackfillRangeTooLong.Message(domain.DefaultLocale, params),
+			Code:    domain.CodeBackfillRangeTooLong,
+			Params:  params,
+		}
+	}
+	return nil
+}
+
+func (s *backfillService) concurrency() int {
+	if s.cfg.Concurrency <= 0 {
+		return defaultBackfillConcurrency
+	}
+	return s.cfg.Concurrency
+}
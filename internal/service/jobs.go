@@ -0,0 +1,400 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/idempotency"
+	"go-clean-template/pkg/listquery"
+	"go-clean-template/pkg/logger"
+	"go-clean-template/pkg/monitoring"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	jobsMetrics = "jobs"
+	// errInterrupted is the error of runs left running by a previous process of the instance
+	errInterrupted = "interrupted"
+)
+
+type JobRunRepository interface {
+	Start(ctx context.Context, run domain.JobRun) error
+	Finish(ctx context.Context, run domain.JobRun) error
+	LastRuns(ctx context.Context) ([]domain.JobRun, error)
+	Runs(ctx context.Context, name string, q listquery.Query) ([]domain.JobRun, error)
+	FailRunning(ctx context.Context, instanceID uuid.UUID, reason string) (int64, error)
+}
+
+type RateLimitRepository interface {
+	Purge(ctx context.Context) (int64, error)
+}
+
+type jobService struct {
+	repo       JobRunRepository
+	audit      domain.AuditService
+	policy     domain.PolicyService
+	events     domain.EventBus
+	instanceID uuid.UUID
+	mu         sync.RWMutex
+	jobs       map[string]domain.JobFunc
+	tracked    map[string]struct{}
+	closed     bool
+	wg         sync.WaitGroup
+	mon        monitoring.Monitoring
+	lg         logger.Logger
+}
+
+func NewJobService(repo JobRunRepository, audit domain.AuditService, policy domain.PolicyService,
+	events domain.EventBus, instanceID uuid.UUID, mon monitoring.Monitoring, lg logger.Logger) *jobService {
+	mon.Register(jobsMetrics)
+	return &jobService{
+		repo:       repo,
+		audit:      audit,
+		policy:     policy,
+		events:     events,
+		instanceID: instanceID,
+		jobs:       make(map[string]domain.JobFunc),
+		tracked:    make(map[string]struct{}),
+		mon:        mon,
+		lg:         lg,
+	}
+}
+
+func (s *jobService) Register(name string, fn domain.JobFunc) {
+	s.mu.Lock()
+	s.jobs[name] = fn
+	s.mu.Unlock()
+}
+
+func (s *jobService) Run(ctx context.Context, name string, trigger domain.JobTrigger,
+	params map[string]string) (domain.JobRun, error) {
+	const op = "jobService.Run"
+
+	fn, err := s.job(name)
+	if err != nil {
+		return domain.JobRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	run, err := s.Track(ctx, name, trigger, params, func(ctx context.Context) error {
+		return fn(ctx, params)
+	})
+	if err != nil {
+		return run, fmt.Errorf("%s: %w", op, err)
+	}
+	return run, nil
+}
+
+func (s *jobService) Trigger(ctx context.Context, name string, params map[string]string) (domain.JobRun, error) {
+	const op = "jobService.Trigger"
+
+	err := domain.Authorize(ctx, s.policy, domain.PermissionJobsTrigger, name)
+	if err != nil {
+		return domain.JobRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	fn, err := s.job(name)
+	if err != nil {
+		return domain.JobRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return domain.JobRun{}, fmt.Errorf("%s: %w", op, domain.NewUnavailableError(domain.CodeStopping, nil))
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	run, err := s.start(ctx, name, domain.JobTriggerManual, params)
+	s.auditTrigger(ctx, name, run, err)
+	if err != nil {
+		s.wg.Done()
+		return domain.JobRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// a manual run outlives the request that triggered it, Close waits for it
+	go func() {
+		defer s.wg.Done()
+		runCtx := context.WithoutCancel(ctx)
+		s.finish(runCtx, run, safeCall(runCtx, func(ctx context.Context) error {
+			return fn(ctx, params)
+		}))
+	}()
+
+	return run, nil
+}
+
+func (s *jobService) Track(ctx context.Context, name string, trigger domain.JobTrigger,
+	params map[string]string, fn func(ctx context.Context) error) (domain.JobRun, error) {
+	const op = "jobService.Track"
+
+	s.mu.Lock()
+	s.tracked[name] = struct{}{}
+	s.mu.Unlock()
+
+	run, err := s.start(ctx, name, trigger, params)
+	if err != nil {
+		return domain.JobRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	jobErr := safeCall(ctx, fn)
+	run = s.finish(ctx, run, jobErr)
+	if jobErr != nil {
+		return run, fmt.Errorf("%s: %w", op, jobErr)
+	}
+	return run, nil
+}
+
+func (s *jobService) Jobs(ctx context.Context) ([]domain.Job, error) {
+	const op = "jobService.Jobs"
+
+	lastRuns, err := s.repo.LastRuns(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	jobs := make(map[string]*domain.JobRun)
+	for _, name := range s.names() {
+		jobs[name] = nil
+	}
+	for i := range lastRuns {
+		jobs[lastRuns[i].JobName] = &lastRuns[i]
+	}
+
+	res := make([]domain.Job, 0, len(jobs))
+	for name, last := range jobs {
+		res = append(res, domain.Job{Name: name, LastRun: last})
+	}
+	slices.SortFunc(res, func(a, b domain.Job) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return res, nil
+}
+
+func (s *jobService) Runs(ctx context.Context, name string, q listquery.Query) ([]domain.JobRun, error) {
+	const op = "jobService.Runs"
+
+	err := s.known(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	runs, err := s.repo.Runs(ctx, name, q)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return runs, nil
+}
+
+// Recover fails the runs a previous process of this instance left running, it finds them only if the
+// instance id is set in the config
+func (s *jobService) Recover(ctx context.Context) error {
+	const op = "jobService.Recover"
+
+	n, err := s.repo.FailRunning(ctx, s.instanceID, errInterrupted)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n > 0 {
+		s.lg.Info(fmt.Sprintf("%s: %d interrupted runs failed", op, n))
+	}
+	return nil
+}
+
+// Close refuses new manual runs and waits for the running ones
+func (s *jobService) Close(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("jobService.Close: %w", ctx.Err())
+	}
+}
+
+func (s *jobService) auditTrigger(ctx context.Context, name string, run domain.JobRun, err error) {
+	entry := domain.AuditEntry{
+		Action:     domain.AuditActionJobTrigger,
+		ResourceID: name,
+		RequestID:  logger.RequestIDFromContext(ctx),
+		Outcome:    domain.AuditOutcomeSuccess,
+	}
+	if err != nil {
+		entry.Outcome = domain.AuditOutcomeFailure
+		entry.Error = err.Error()
+	} else {
+		entry.ResourceID = name + "/" + run.ID.String()
+	}
+
+	if err := s.audit.Record(ctx, entry); err != nil {
+		s.lg.WithContext(ctx).Error(fmt.Errorf("job %s: %w", name, err))
+	}
+}
+
+func (s *jobService) job(name string) (domain.JobFunc, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn, ok := s.jobs[name]
+	if !ok {
+		return nil, domain.NewNotFoundError(domain.CodeJobNotFound, domain.Params{"job": name})
+	}
+	return fn, nil
+}
+
+// names returns the registered jobs and the jobs recorded with Track, which have runs but no JobFunc
+func (s *jobService) names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.jobs)+len(s.tracked))
+	for name := range s.jobs {
+		names = append(names, name)
+	}
+	for name := range s.tracked {
+		names = append(names, name)
+	}
+	return names
+}
+
+// known returns NotFoundError unless the job is registered, tracked or has runs, the same jobs Jobs lists
+func (s *jobService) known(ctx context.Context, name string) error {
+	if slices.Contains(s.names(), name) {
+		return nil
+	}
+	lastRuns, err := s.repo.LastRuns(ctx)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(lastRuns, func(run domain.JobRun) bool { return run.JobName == name }) {
+		return nil
+	}
+	return domain.NewNotFoundError(domain.CodeJobNotFound, domain.Params{"job": name})
+}
+
+// safeCall turns a panic of the job into its error, so the run is finished and the process survives
+func safeCall(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v, stacktrace: %s", r, debug.Stack())
+		}
+	}()
+	return fn(ctx)
+}
+
+func (s *jobService) start(ctx context.Context, name string, trigger domain.JobTrigger,
+	params map[string]string) (domain.JobRun, error) {
+	if params == nil {
+		params = map[string]string{}
+	}
+	run := domain.JobRun{
+		ID:         uuid.New(),
+		JobName:    name,
+		InstanceID: s.instanceID,
+		Trigger:    trigger,
+		Status:     domain.JobStatusRunning,
+		Params:     params,
+		StartedAt:  time.Now(),
+	}
+
+	err := s.repo.Start(ctx, run)
+	if err != nil {
+		return domain.JobRun{}, err
+	}
+	s.lg.WithContext(ctx).Info(fmt.Sprintf("job %s run %s started by %s with params %v", name, run.ID, trigger, params))
+	s.publish(ctx, domain.JobRunStarted{JobRun: run})
+
+	return run, nil
+}
+
+func (s *jobService) finish(ctx context.Context, run domain.JobRun, jobErr error) domain.JobRun {
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.Status = domain.JobStatusSucceeded
+	if jobErr != nil {
+		run.Status = domain.JobStatusFailed
+		run.Error = jobErr.Error()
+		s.lg.WithContext(ctx).Error(fmt.Errorf("job %s run %s: %w", run.JobName, run.ID, jobErr))
+	}
+
+	duration := finishedAt.Sub(run.StartedAt)
+	s.mon.Observe(jobsMetrics, run.JobName, duration.Seconds())
+	s.mon.Count(jobsMetrics, run.JobName, jobErr != nil)
+
+	// the run must be closed even if ctx of the job is done
+	err := s.repo.Finish(context.WithoutCancel(ctx), run)
+	if err != nil {
+		s.lg.WithContext(ctx).Error(fmt.Errorf("job %s run %s: %w", run.JobName, run.ID, err))
+	}
+	s.lg.WithContext(ctx).Info(fmt.Sprintf("job %s run %s %s in %v", run.JobName, run.ID, run.Status, duration))
+	s.publish(context.WithoutCancel(ctx), domain.JobRunFinished{JobRun: run})
+
+	return run
+}
+
+// publish only logs errors, a run is not failed by its subscribers
+func (s *jobService) publish(ctx context.Context, e domain.Event) {
+	if err := s.events.Publish(ctx, e); err != nil {
+		s.lg.WithContext(ctx).Error(fmt.Errorf("job event %s: %w", e.EventName(), err))
+	}
+}
+
+// PersistJob persists the day passed in "dt" param
+func PersistJob(service domain.Service) domain.JobFunc {
+	return func(ctx context.Context, params map[string]string) error {
+		dt := params["dt"]
+		if _, err := time.Parse(time.DateOnly, dt); err != nil {
+			return domain.NewValidationError(
+				domain.NewFieldError("dt", domain.CodeFieldInvalidDate, domain.Params{"param": time.DateOnly}))
+		}
+		return service.Persist(ctx, dt)
+	}
+}
+
+// IdempotencyPurgeJob deletes expired idempotency keys
+func IdempotencyPurgeJob(repo idempotency.Repository, lg logger.Logger) domain.JobFunc {
+	return func(ctx context.Context, _ map[string]string) error {
+		n, err := repo.Purge(ctx)
+		if err != nil {
+			return err
+		}
+		lg.Info(fmt.Sprintf("idempotency keys purged: %d", n))
+		return nil
+	}
+}
+
+// RateLimitPurgeJob deletes rate limit buckets that are full again
+func RateLimitPurgeJob(repo RateLimitRepository, lg logger.Logger) domain.JobFunc {
+	return func(ctx context.Context, _ map[string]string) error {
+		n, err := repo.Purge(ctx)
+		if err != nil {
+			return err
+		}
+		lg.Info(fmt.Sprintf("rate limit buckets purged: %d", n))
+		return nil
+	}
+}
+
+// OutboxPurgeJob deletes outbox messages published longer than retention ago
+func OutboxPurgeJob(repo OutboxRepository, retention time.Duration, lg logger.Logger) domain.JobFunc {
+	return func(ctx context.Context, _ map[string]string) error {
+		n, err := repo.Purge(ctx, time.Now().Add(-retention))
+		if err != nil {
+			return err
+		}
+		lg.Info(fmt.Sprintf("outbox messages purged: %d", n))
+		return nil
+	}
+}
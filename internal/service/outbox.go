@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-clean-template/config"
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/logger"
+	"go-clean-template/pkg/monitoring"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	outboxMetrics = "outbox"
+
+	defaultOutboxInterval  = time.Second
+	defaultOutboxBatchSize = 100
+)
+
+type OutboxRepository interface {
+	Unpublished(ctx context.Context, limit int) ([]domain.OutboxMessage, error)
+	MarkPublished(ctx context.Context, ids []uuid.UUID) error
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+type outboxRelay struct {
+	repo  OutboxRepository
+	tx    domain.Transactor
+	relay domain.EventRelay
+	cfg   config.Outbox
+	mon   monitoring.Monitoring
+	lg    logger.Logger
+}
+
+// NewOutboxRelay publishes the outbox to every instance with relay, Run starts it
+func NewOutboxRelay(repo OutboxRepository, tx domain.Transactor, relay domain.EventRelay, cfg config.Outbox,
+	mon monitoring.Monitoring, lg logger.Logger) *outboxRelay {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultOutboxInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultOutboxBatchSize
+	}
+	mon.Register(outboxMetrics)
+	return &outboxRelay{
+		repo:  repo,
+		tx:    tx,
+		relay: relay,
+		cfg:   cfg,
+		mon:   mon,
+		lg:    lg,
+	}
+}
+
+// Run drains the outbox every interval until ctx is done, full batches are followed at once
+func (o *outboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for {
+			n, err := o.Drain(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					o.lg.Error(fmt.Errorf("outboxRelay.Run: %w", err))
+				}
+				break
+			}
+			if n < o.cfg.BatchSize {
+				break
+			}
+		}
+	}
+}
+
+// Drain relays a batch of unpublished messages and marks them published in one transaction, the relay
+// delivers them on commit. A message too large to relay is logged and marked too, it can never be delivered.
+func (o *outboxRelay) Drain(ctx context.Context) (int, error) {
+	const op = "outboxRelay.Drain"
+
+	var n int
+	err := o.tx.InTx(ctx, func(ctx context.Context) error {
+		msgs, err := o.repo.Unpublished(ctx, o.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(msgs) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(msgs))
+		for _, msg := range msgs {
+			err := o.relay.Relay(ctx, msg)
+			if err != nil && !errors.Is(err, domain.ErrEventTooLarge) {
+				return err
+			}
+			if err != nil {
+				o.lg.WithContext(ctx).Error(fmt.Errorf("%s: message %s: %w", op, msg.ID, err))
+			}
+			o.mon.Count(outboxMetrics, msg.Name, err != nil)
+			ids = append(ids, msg.ID)
+		}
+		n = len(ids)
+		return o.repo.MarkPublished(ctx, ids)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return n, nil
+}
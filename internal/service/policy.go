@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-clean-template/config"
+	"go-clean-template/internal/domain"
+	"go-clean-template/pkg/logger"
+	"go-clean-template/pkg/monitoring"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	policyMetrics         = "policy"
+	policyRefreshTimeout  = 10 * time.Second
+	defaultPolicyInterval = time.Minute
+)
+
+type PolicyRepository interface {
+	Load(ctx context.Context) (domain.Policy, error)
+}
+
+// policyService serves decisions from a snapshot, roles stored in Postgres are refreshed in background
+type policyService struct {
+	repo       PolicyRepository
+	mu         sync.Mutex
+	cfg        config.Policy
+	static     domain.Policy
+	stored     domain.Policy
+	policy     atomic.Pointer[domain.Policy]
+	enabled    atomic.Bool
+	loadedAt   atomic.Int64
+	refreshing atomic.Bool
+	mon        monitoring.Monitoring
+	lg         logger.Logger
+}
+
+// NewPolicyService loads the stored policy once, repo may be nil if the policy is only in config
+func NewPolicyService(ctx context.Context, cfg config.Policy, repo PolicyRepository, mon monitoring.Monitoring,
+	lg logger.Logger) (*policyService, error) {
+	const op = "service.NewPolicyService"
+	mon.Register(policyMetrics)
+
+	s := &policyService{
+		repo: repo,
+		mon:  mon,
+		lg:   lg,
+	}
+	s.Apply(cfg)
+	if cfg.Enabled {
+		err := s.Refresh(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return s, nil
+}
+
+// Apply replaces the config part of the policy, it is called on config reload
+func (s *policyService) Apply(cfg config.Policy) {
+	static := domain.Policy{
+		Roles:    make([]domain.Role, 0, len(cfg.Roles)),
+		Bindings: make([]domain.RoleBinding, 0, len(cfg.Bindings)),
+		Routes:   make([]domain.RoutePermission, 0, len(cfg.Routes)),
+	}
+	for _, r := range cfg.Roles {
+		static.Roles = append(static.Roles, domain.Role{Name: r.Name, Permissions: r.Permissions})
+	}
+	for _, b := range cfg.Bindings {
+		static.Bindings = append(static.Bindings, domain.RoleBinding{Subject: b.Subject, Roles: b.Roles})
+	}
+	static.Bindings = s.qualified(static.Bindings, "config")
+	for _, r := range cfg.Routes {
+		static.Routes = append(static.Routes,
+			domain.RoutePermission{Method: r.Method, Path: r.Path, Permission: r.Permission})
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = defaultPolicyInterval
+	}
+
+	s.mu.Lock()
+	s.cfg = cfg
+	s.static = static
+	s.merge()
+	s.mu.Unlock()
+	s.enabled.Store(cfg.Enabled)
+}
+
+// Refresh reloads the roles, bindings and routes stored in Postgres
+func (s *policyService) Refresh(ctx context.Context) error {
+	const op = "policyService.Refresh"
+
+	s.mu.Lock()
+	load := s.cfg.Postgres && s.repo != nil
+	s.mu.Unlock()
+	if !load {
+		s.loadedAt.Store(time.Now().UnixNano())
+		return nil
+	}
+
+	stored, err := s.repo.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	stored.Bindings = s.qualified(stored.Bindings, "postgres")
+	s.mu.Lock()
+	s.stored = stored
+	s.merge()
+	s.mu.Unlock()
+	s.loadedAt.Store(time.Now().UnixNano())
+
+	return nil
+}
+
+func (s *policyService) Enabled() bool {
+	return s.enabled.Load()
+}
+
+func (s *policyService) Policy() domain.Policy {
+	s.refreshIfStale()
+	return *s.policy.Load()
+}
+
+func (s *policyService) Decide(ctx context.Context, p domain.Principal, permission,
+	resource string) domain.Decision {
+	s.refreshIfStale()
+	d := s.policy.Load().Decide(p, permission, resource)
+	s.mon.Count(policyMetrics, permission, !d.Allowed)
+	if !d.Allowed {
+		s.lg.WithContext(ctx).Warning(
+			fmt.Sprintf("policy denied %s to %s (roles %v): %s", d.Permission, d.Subject, d.Roles, d.Reason))
+	}
+	return d
+}
+
+func (s *policyService) RoutePermission(method, path string) (string, bool) {
+	s.refreshIfStale()
+	return s.policy.Load().RoutePermission(method, path)
+}
+
+func (s *policyService) Explain(_ context.Context, req domain.ExplainRequest) (domain.Decision, error) {
+	pol := s.Policy()
+	permission := req.Permission
+	if permission == "" {
+		var ok bool
+		permission, ok = pol.RoutePermission(req.Method, req.Path)
+		if !ok {
+			return domain.Decision{
+				Allowed: true,
+				Subject: req.Subject,
+				Roles:   pol.RolesOf(req.Principal()),
+				Reason:  fmt.Sprintf("no permission is bound to %s %s", req.Method, req.Path),
+			}, nil
+		}
+	}
+
+	d := pol.Decide(req.Principal(), permission, req.Resource)
+	if !s.Enabled() {
+		d.Allowed = true
+		d.Reason = "policy is disabled, " + d.Reason
+	}
+	return d, nil
+}
+
+// qualified drops bindings of subjects without auth method, a token subject must not get the roles
+// of an API key or a certificate with the same name
+func (s *policyService) qualified(bindings []domain.RoleBinding, source string) []domain.RoleBinding {
+	res := make([]domain.RoleBinding, 0, len(bindings))
+	for _, b := range bindings {
+		if _, ok := domain.ParseSubject(b.Subject); !ok && b.Subject != domain.PolicyAnySubject {
+			s.lg.Warning(fmt.Sprintf("policy binding of %s in %s is ignored: the subject must be prefixed with "+
+				"the auth method like jwt:%s", b.Subject, source, b.Subject))
+			continue
+		}
+		res = append(res, b)
+	}
+	return res
+}
+
+// merge must be called with mu held, stored roles are appended after the ones from config
+func (s *policyService) merge() {
+	pol := domain.Policy{
+		Roles:    append(append([]domain.Role{}, s.static.Roles...), s.stored.Roles...),
+		Bindings: append(append([]domain.RoleBinding{}, s.static.Bindings...), s.stored.Bindings...),
+		Routes:   append(append([]domain.RoutePermission{}, s.static.Routes...), s.stored.Routes...),
+	}
+	s.policy.Store(&pol)
+}
+
+func (s *policyService) refreshIfStale() {
+	s.mu.Lock()
+	interval := s.cfg.RefreshInterval
+	load := s.cfg.Postgres && s.repo != nil
+	s.mu.Unlock()
+	if !load || time.Since(time.Unix(0, s.loadedAt.Load())) < interval {
+		return
+	}
+	if !s.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	// decisions are served from the previous snapshot until the refresh is done
+	go func() {
+		defer s.refreshing.Store(false)
+		ctx, cancel := context.WithTimeout(context.Background(), policyRefreshTimeout)
+		defer cancel()
+		if err := s.Refresh(ctx); err != nil {
+			// the next attempt waits for another interval
+			s.loadedAt.Store(time.Now().UnixNano())
+			s.lg.Error(err)
+		}
+	}()
+}
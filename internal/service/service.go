@@ -2,23 +2,37 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"go-clean-template/internal/domain"
 	"go-clean-template/pkg/logger"
+	"time"
 )
 
 type service struct {
-	lg logger.Logger
+	events domain.EventBus
+	tx     domain.Transactor
+	lg     logger.Logger
 }
 
-func NewService(lg logger.Logger) *service {
-	return &service{lg: lg}
+func NewService(events domain.EventBus, tx domain.Transactor, lg logger.Logger) *service {
+	return &service{events: events, tx: tx, lg: lg}
 }
 
 func (s *service) Do(ctx context.Context, req domain.ServiceRequest) error {
-	s.lg.Info("Do service")
+	s.lg.WithContext(ctx).Info("Do service")
 	return nil
 }
 
 func (s *service) Persist(ctx context.Context, dt string) error {
+	const op = "service.Persist"
+
+	// writes of the day go into the same transaction as the outbox row of the event, so the event
+	// is stored only with the data and subscribers hear of it after commit
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		return s.events.Publish(ctx, domain.PersistCompleted{Date: dt, FinishedAt: time.Now()})
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 	return nil
 }
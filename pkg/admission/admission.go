@@ -0,0 +1,198 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrQueueFull rejects a request when the queue has no room and no lower priority request to shed
+	ErrQueueFull = errors.New("admission queue is full")
+	// ErrShed rejects a queued request in favour of a request of higher priority
+	ErrShed = errors.New("request shed for a higher priority one")
+	// ErrTimeout rejects a request that waited in the queue for too long
+	ErrTimeout = errors.New("admission queue timeout")
+)
+
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+func ParsePriority(s string) (Priority, error) {
+	switch s {
+	case "low":
+		return PriorityLow, nil
+	case "normal", "":
+		return PriorityNormal, nil
+	case "high":
+		return PriorityHigh, nil
+	}
+	return 0, fmt.Errorf("unknown priority %q", s)
+}
+
+type Options struct {
+	InitialLimit int
+	MinLimit     int
+	MaxLimit     int
+	// TargetLatency is the latency above which the limit decreases
+	TargetLatency time.Duration
+	// Backoff multiplies the limit on a slow request, it is in (0, 1)
+	Backoff      float64
+	MaxQueue     int
+	QueueTimeout time.Duration
+}
+
+type waiter struct {
+	priority Priority
+	ready    chan error
+	// granted is set under mu when the waiter got a slot, a timed out waiter must release it
+	granted bool
+}
+
+// Controller admits requests up to a concurrency limit and queues the rest by priority.
+// The limit grows by one per limit of fast requests and is multiplied by Backoff on a slow one (AIMD).
+type Controller struct {
+	opts     Options
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	// queue is ordered by priority descending, FIFO within a priority
+	queue []*waiter
+}
+
+func New(opts Options) *Controller {
+	opts.MinLimit = max(1, opts.MinLimit)
+	opts.MaxLimit = max(opts.MinLimit, opts.MaxLimit)
+	if opts.Backoff <= 0 || opts.Backoff >= 1 {
+		opts.Backoff = 0.9
+	}
+	initial := min(max(opts.InitialLimit, opts.MinLimit), opts.MaxLimit)
+	return &Controller{
+		opts:  opts,
+		limit: float64(initial),
+	}
+}
+
+// Acquire waits for a slot, done must be called with the latency of the request once it is served
+func (c *Controller) Acquire(ctx context.Context, p Priority) (func(latency time.Duration), error) {
+	c.mu.Lock()
+	if c.inFlight < c.capacity() && len(c.queue) == 0 {
+		c.inFlight++
+		c.mu.Unlock()
+		return c.done, nil
+	}
+
+	if len(c.queue) >= c.opts.MaxQueue {
+		last := len(c.queue) - 1
+		if last < 0 || c.queue[last].priority >= p {
+			c.mu.Unlock()
+			return nil, ErrQueueFull
+		}
+		c.queue[last].ready <- ErrShed
+		c.queue = c.queue[:last]
+	}
+	w := &waiter{priority: p, ready: make(chan error, 1)}
+	c.enqueue(w)
+	c.mu.Unlock()
+
+	timer := time.NewTimer(c.opts.QueueTimeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case err = <-w.ready:
+		if err != nil {
+			return nil, err
+		}
+		return c.done, nil
+	case <-timer.C:
+		err = ErrTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if w.granted {
+		// the slot was granted while giving up, pass it on
+		c.inFlight--
+		c.dispatch()
+		return nil, err
+	}
+	c.remove(w)
+	return nil, err
+}
+
+// Limit, InFlight and Queued are the current state of the controller for metrics
+func (c *Controller) Limit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.capacity()
+}
+
+func (c *Controller) InFlight() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.inFlight
+}
+
+func (c *Controller) Queued() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.queue)
+}
+
+func (c *Controller) done(latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.inFlight--
+	if latency > c.opts.TargetLatency {
+		c.limit = math.Max(float64(c.opts.MinLimit), c.limit*c.opts.Backoff)
+	} else {
+		c.limit = math.Min(float64(c.opts.MaxLimit), c.limit+1/c.limit)
+	}
+	c.dispatch()
+}
+
+// dispatch grants free slots to the queue head, it must be called with mu held
+func (c *Controller) dispatch() {
+	for len(c.queue) > 0 && c.inFlight < c.capacity() {
+		w := c.queue[0]
+		c.queue = c.queue[1:]
+		w.granted = true
+		c.inFlight++
+		w.ready <- nil
+	}
+}
+
+func (c *Controller) capacity() int {
+	return int(c.limit)
+}
+
+func (c *Controller) enqueue(w *waiter) {
+	i := len(c.queue)
+	for i > 0 && c.queue[i-1].priority < w.priority {
+		i--
+	}
+	c.queue = append(c.queue, nil)
+	copy(c.queue[i+1:], c.queue[i:])
+	c.queue[i] = w
+}
+
+func (c *Controller) remove(w *waiter) {
+	for i := range c.queue {
+		if c.queue[i] == w {
+			c.queue = append(c.queue[:i], c.queue[i+1:]...)
+			return
+		}
+	}
+}
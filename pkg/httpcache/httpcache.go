@@ -0,0 +1,98 @@
+// Package httpcache implements validators and conditional requests of RFC 9110.
+// Handlers that know the version of their data set validators themselves and answer 304 before building
+// the response, other responses get an ETag computed from their body.
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ETag returns a strong validator of the bytes of a representation
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+// WeakETag returns a weak validator of a version supplied by the service, like an update time or a revision
+func WeakETag(version string) string {
+	return `W/"` + strings.ReplaceAll(version, `"`, "") + `"`
+}
+
+// IsWeak reports whether etag is a weak validator
+func IsWeak(etag string) bool {
+	return strings.HasPrefix(etag, "W/")
+}
+
+// NoneMatch reports whether If-None-Match doesn't match etag, the comparison is weak
+func NoneMatch(ifNoneMatch, etag string) bool {
+	if etag == "" {
+		return true
+	}
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return false
+		}
+	}
+	return true
+}
+
+// ModifiedSince reports whether lastModified is after If-Modified-Since, dates have a precision of seconds
+func ModifiedSince(ifModifiedSince string, lastModified time.Time) bool {
+	if lastModified.IsZero() {
+		return true
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return true
+	}
+	return lastModified.Truncate(time.Second).After(since)
+}
+
+// Fresh reports whether the client's copy is current. If-None-Match takes precedence over If-Modified-Since.
+func Fresh(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return !NoneMatch(inm, etag)
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		return !ModifiedSince(ims, lastModified)
+	}
+	return false
+}
+
+// SetValidators sets ETag and Last-Modified, empty and zero values are skipped
+func SetValidators(h http.Header, etag string, lastModified time.Time) {
+	if etag != "" {
+		h.Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		h.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// NotModified sets the validators and answers 304 if the client's copy is current,
+// the handler returns without building the response if it is true
+func NotModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	SetValidators(w.Header(), etag, lastModified)
+	if !Fresh(r, etag, lastModified) {
+		return false
+	}
+	WriteNotModified(w)
+	return true
+}
+
+// WriteNotModified answers 304, representation headers are removed as the response has no content
+func WriteNotModified(w http.ResponseWriter) {
+	h := w.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	h.Del("Content-Encoding")
+	w.WriteHeader(http.StatusNotModified)
+}
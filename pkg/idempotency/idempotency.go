@@ -0,0 +1,65 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+)
+
+type State string
+
+const (
+	StateProcessing State = "processing"
+	StateCompleted  State = "completed"
+)
+
+type Record struct {
+	Key         string
+	RequestHash string
+	State       State
+	Status      int
+	Header      http.Header
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+type Repository interface {
+	// Acquire locks key for a new request. If the key is already taken by a live record
+	// it returns that record and false. Expired records and locks older than lockTimeout are taken over.
+	Acquire(ctx context.Context, key, requestHash string, ttl, lockTimeout time.Duration) (Record, bool, error)
+	// Complete stores the response of the request that holds the key
+	Complete(ctx context.Context, rec Record) error
+	// Release drops the key so the request can be retried
+	Release(ctx context.Context, key string) error
+	// Purge deletes expired records
+	Purge(ctx context.Context) (int64, error)
+}
+
+// ScopedKey is the stored key, the key of a client only identifies requests of the same principal to the same route
+func ScopedKey(principal, method, route, key string) string {
+	h := sha256.New()
+	for _, part := range []string{principal, method, route} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte(key))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RequestHash fingerprints the request, the same key with another fingerprint is a misuse
+func RequestHash(method, uri string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(uri))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
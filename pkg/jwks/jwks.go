@@ -0,0 +1,198 @@
+// Package jwks loads verification keys from a local JSON Web Key Set file (RFC 7517).
+// The file is read again when it changes, checked at most once per refresh interval
+// or when a token refers to an unknown key id.
+package jwks
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrKeyNotFound = errors.New("jwks: key not found")
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// symmetric
+	K string `json:"k"`
+}
+
+type Key struct {
+	ID  string
+	Alg string
+	// Public is *rsa.PublicKey, *ecdsa.PublicKey or []byte for symmetric keys
+	Public any
+}
+
+type keySet struct {
+	file    string
+	refresh time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]Key
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+const defaultRefresh = time.Minute
+
+func New(file string, refresh time.Duration) (*keySet, error) {
+	if refresh <= 0 {
+		refresh = defaultRefresh
+	}
+	s := &keySet{
+		file:    file,
+		refresh: refresh,
+	}
+	if err := s.reload(true); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Key returns the key by id, the file is re-read if it may be stale
+func (s *keySet) Key(kid string) (Key, error) {
+	s.mu.RLock()
+	k, ok := s.keys[kid]
+	stale := time.Since(s.checkedAt) >= s.refresh
+	s.mu.RUnlock()
+
+	if ok && !stale {
+		return k, nil
+	}
+	// reload reads the file only if it's modified, so unknown ids cost a stat call
+	if err := s.reload(false); err != nil {
+		return Key{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok = s.keys[kid]
+	if !ok {
+		return Key{}, fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+	}
+	return k, nil
+}
+
+func (s *keySet) reload(force bool) error {
+	const op = "jwks.reload"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkedAt = time.Now()
+	st, err := os.Stat(s.file)
+	if err != nil {
+		return fmt.Errorf("%s: os.Stat: %w", op, err)
+	}
+	if !force && !st.ModTime().After(s.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		return fmt.Errorf("%s: os.ReadFile: %w", op, err)
+	}
+	keys, err := Parse(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	s.keys = keys
+	s.modTime = st.ModTime()
+	return nil
+}
+
+// Parse parses a key set, keys with "use" other than "sig" are skipped
+func Parse(data []byte) (map[string]Key, error) {
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	keys := make(map[string]Key, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.public()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = Key{k.Kid, k.Alg, pub}
+	}
+	return keys, nil
+}
+
+func (k jwk) public() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %w", err)
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("e: %w", err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) { //nolint:staticcheck //there is no replacement for big.Int coordinates
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return nil, fmt.Errorf("k: %w", err)
+		}
+		return secret, nil
+	default:
+		return nil, fmt.Errorf("unsupported kty %q", k.Kty)
+	}
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,147 @@
+package listquery
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-clean-template/pkg/validate"
+
+	"github.com/google/uuid"
+)
+
+// Signer signs cursors, all instances behind one address need the same secret
+type Signer struct {
+	key []byte
+}
+
+// NewSigner uses a random key if secret is empty, cursors are then valid only in this process
+func NewSigner(secret string) *Signer {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, sha256.Size)
+		_, _ = rand.Read(key)
+	}
+	return &Signer{key: key}
+}
+
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+type cursor struct {
+	Fingerprint string `json:"f"`
+	Values      []any  `json:"v"`
+}
+
+func (q Query) encodeCursor(values []any) string {
+	b, _ := json.Marshal(cursor{Fingerprint: q.fingerprint, Values: values})
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + q.signer.sign(payload)
+}
+
+func (q Query) decodeCursor(c string) ([]any, *validate.FieldError) {
+	invalid := typeError(ParamCursor, "a cursor of this query")
+	payload, sig, ok := strings.Cut(c, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(q.signer.sign(payload))) {
+		return nil, invalid
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, invalid
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var cur cursor
+	if err := dec.Decode(&cur); err != nil || cur.Fingerprint != q.fingerprint || len(cur.Values) != len(q.Order) {
+		return nil, invalid
+	}
+
+	after := make([]any, len(q.Order))
+	for i, o := range q.Order {
+		v, ok := q.spec.Fields[o.Field].fromJSON(cur.Values[i])
+		if !ok {
+			return nil, invalid
+		}
+		after[i] = v
+	}
+	return after, nil
+}
+
+// fromJSON converts a decoded cursor value back to the type of the field
+func (f Field) fromJSON(v any) (any, bool) {
+	switch f.Type {
+	case Int:
+		n, ok := v.(json.Number)
+		if !ok {
+			return nil, false
+		}
+		i, err := n.Int64()
+		return i, err == nil
+	case Time:
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return t, err == nil
+	case UUID:
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		id, err := uuid.Parse(s)
+		return id, err == nil
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+// Page cuts the extra row fetched by Build and returns the cursor of the next page, "" on the last page.
+// value returns the value of a field of a row, it is called for the fields of the order.
+func Page[T any](q Query, rows []T, value func(row T, field string) any) ([]T, string) {
+	if len(rows) <= q.Limit {
+		return rows, ""
+	}
+	rows = rows[:q.Limit]
+	last := rows[len(rows)-1]
+	values := make([]any, len(q.Order))
+	for i, o := range q.Order {
+		values[i] = value(last, o.Field)
+	}
+	return rows, q.encodeCursor(values)
+}
+
+// Result is the body of list responses
+type Result[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Next       string `json:"next,omitempty"`
+}
+
+// NewResult links the next page to the request URL u
+func NewResult[T any](rows []T, next string, u *url.URL) Result[T] {
+	if rows == nil {
+		rows = []T{}
+	}
+	res := Result[T]{Data: rows, NextCursor: next}
+	if next != "" {
+		res.Next = NextURL(u, next)
+	}
+	return res
+}
+
+// NextURL returns the path and query of u with the cursor of the next page
+func NextURL(u *url.URL, cursor string) string {
+	values := u.Query()
+	values.Set(ParamCursor, cursor)
+	return u.Path + "?" + values.Encode()
+}
@@ -0,0 +1,288 @@
+// Package listquery parses the parameters of list endpoints against an allowlist of fields and translates
+// them to parameterized SQL with keyset pagination:
+//
+//	?limit=50&sort=-created_at,status&outcome=failure&created_at[gte]=2024-01-01&cursor=...
+//
+// Filters are field=value or field[op]=value, in takes comma separated values. Cursors are opaque and signed,
+// a cursor is accepted only with the sort and filters it was issued for.
+package listquery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-clean-template/pkg/validate"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ParamLimit  = "limit"
+	ParamCursor = "cursor"
+	ParamSort   = "sort"
+)
+
+type Type int
+
+const (
+	String Type = iota
+	Int
+	Time
+	UUID
+)
+
+const (
+	OpEq     = "eq"
+	OpNe     = "ne"
+	OpLt     = "lt"
+	OpLte    = "lte"
+	OpGt     = "gt"
+	OpGte    = "gte"
+	OpIn     = "in"
+	OpPrefix = "prefix"
+)
+
+type Field struct {
+	// Column is the SQL expression of the field, it comes from code and never from the request
+	Column string
+	Type   Type
+	Filter bool
+	Sort   bool
+	// Values limits filter values of a string field
+	Values []string
+}
+
+type Spec struct {
+	Fields map[string]Field
+	// Key is a unique not null field, it ends every order, so pages don't skip or repeat rows
+	Key string
+	// Sort is the default order, fields are separated by commas and prefixed with "-" for descending order
+	Sort         string
+	DefaultLimit int
+	MaxLimit     int
+}
+
+type Order struct {
+	Field string
+	Desc  bool
+}
+
+type Filter struct {
+	Field  string
+	Op     string
+	Values []any
+}
+
+type Query struct {
+	Limit   int
+	Order   []Order
+	Filters []Filter
+	// After holds the values of the order fields of the last row of the previous page
+	After []any
+
+	spec        *Spec
+	signer      *Signer
+	fingerprint string
+}
+
+// Parse reads the list parameters of values, parameters that are not fields of spec are violations
+func Parse(values url.Values, spec *Spec, signer *Signer) (Query, []validate.FieldError) {
+	q := Query{Limit: spec.DefaultLimit, spec: spec, signer: signer}
+	var errs []validate.FieldError
+
+	if v := values.Get(ParamLimit); v != "" {
+		n, err := strconv.Atoi(v)
+		switch {
+		case err != nil:
+			errs = append(errs, *typeError(ParamLimit, "integer"))
+		case n < 1:
+			errs = append(errs, validate.FieldError{Field: ParamLimit, Rule: validate.RuleMin, Param: "1",
+				Message: "must be at least 1"})
+		case spec.MaxLimit > 0 && n > spec.MaxLimit:
+			limit := strconv.Itoa(spec.MaxLimit)
+			errs = append(errs, validate.FieldError{Field: ParamLimit, Rule: validate.RuleMax, Param: limit,
+				Message: "must be at most " + limit})
+		default:
+			q.Limit = n
+		}
+	}
+
+	sort := values.Get(ParamSort)
+	if sort == "" {
+		sort = spec.Sort
+	}
+	order, err := spec.parseOrder(sort)
+	if err != nil {
+		errs = append(errs, *err)
+	}
+	q.Order = order
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if name == ParamLimit || name == ParamSort || name == ParamCursor {
+			continue
+		}
+		for _, raw := range values[name] {
+			f, err := spec.parseFilter(name, raw)
+			if err != nil {
+				errs = append(errs, *err)
+				continue
+			}
+			q.Filters = append(q.Filters, f)
+		}
+	}
+	if len(errs) > 0 {
+		return q, errs
+	}
+
+	q.fingerprint = fingerprint(values, q.Order)
+	if c := values.Get(ParamCursor); c != "" {
+		after, err := q.decodeCursor(c)
+		if err != nil {
+			return q, []validate.FieldError{*err}
+		}
+		q.After = after
+	}
+	return q, nil
+}
+
+func (s *Spec) parseOrder(sort string) ([]Order, *validate.FieldError) {
+	var order []Order
+	for _, part := range strings.Split(sort, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		o := Order{Field: part}
+		if name, ok := strings.CutPrefix(part, "-"); ok {
+			o = Order{Field: name, Desc: true}
+		}
+		if f, ok := s.Fields[o.Field]; !ok || !f.Sort {
+			return nil, s.oneOf(ParamSort, func(f Field) bool { return f.Sort })
+		}
+		if !slices.ContainsFunc(order, func(e Order) bool { return e.Field == o.Field }) {
+			order = append(order, o)
+		}
+	}
+	if !slices.ContainsFunc(order, func(o Order) bool { return o.Field == s.Key }) {
+		desc := len(order) > 0 && order[len(order)-1].Desc
+		order = append(order, Order{Field: s.Key, Desc: desc})
+	}
+	return order, nil
+}
+
+// parseFilter reads field=value and field[op]=value
+func (s *Spec) parseFilter(param, raw string) (Filter, *validate.FieldError) {
+	name, op := param, OpEq
+	if i := strings.IndexByte(param, '['); i > 0 && strings.HasSuffix(param, "]") {
+		name, op = param[:i], param[i+1:len(param)-1]
+	}
+	f, ok := s.Fields[name]
+	if !ok || !f.Filter {
+		return Filter{}, s.oneOf(param, func(f Field) bool { return f.Filter })
+	}
+	ops := Operators(f.Type)
+	if !slices.Contains(ops, op) {
+		return Filter{}, &validate.FieldError{Field: param, Rule: validate.RuleOneOf, Param: strings.Join(ops, "|"),
+			Message: "operator must be one of " + strings.Join(ops, ", ")}
+	}
+
+	parts := []string{raw}
+	if op == OpIn {
+		parts = strings.Split(raw, ",")
+	}
+	filter := Filter{Field: name, Op: op}
+	for _, p := range parts {
+		v, err := f.parse(param, strings.TrimSpace(p))
+		if err != nil {
+			return Filter{}, err
+		}
+		filter.Values = append(filter.Values, v)
+	}
+	return filter, nil
+}
+
+// Operators returns the filter operators of fields of type t
+func Operators(t Type) []string {
+	switch t {
+	case Int, Time:
+		return []string{OpEq, OpNe, OpLt, OpLte, OpGt, OpGte, OpIn}
+	case UUID:
+		return []string{OpEq, OpNe, OpIn}
+	}
+	return []string{OpEq, OpNe, OpIn, OpPrefix}
+}
+
+func (f Field) parse(param, v string) (any, *validate.FieldError) {
+	switch f.Type {
+	case Int:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, typeError(param, "integer")
+		}
+		return n, nil
+	case Time:
+		for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+		return nil, &validate.FieldError{Field: param, Rule: validate.RuleDate, Param: time.RFC3339,
+			Message: "must be a date in format " + time.RFC3339 + " or " + time.DateOnly}
+	case UUID:
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, typeError(param, "uuid")
+		}
+		return id, nil
+	}
+	if len(f.Values) > 0 && !slices.Contains(f.Values, v) {
+		return nil, &validate.FieldError{Field: param, Rule: validate.RuleOneOf, Param: strings.Join(f.Values, "|"),
+			Message: "must be one of " + strings.Join(f.Values, ", ")}
+	}
+	return v, nil
+}
+
+func (s *Spec) oneOf(param string, allowed func(f Field) bool) *validate.FieldError {
+	names := make([]string, 0, len(s.Fields))
+	for name, f := range s.Fields {
+		if allowed(f) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return &validate.FieldError{Field: param, Rule: validate.RuleOneOf, Param: strings.Join(names, "|"),
+		Message: "must be one of " + strings.Join(names, ", ")}
+}
+
+func typeError(field, typ string) *validate.FieldError {
+	return &validate.FieldError{Field: field, Rule: validate.RuleType, Param: typ, Message: "must be " + typ}
+}
+
+// fingerprint identifies the order and the filters of a query, a cursor is bound to them
+func fingerprint(values url.Values, order []Order) string {
+	rest := url.Values{}
+	for name, v := range values {
+		if name != ParamLimit && name != ParamSort && name != ParamCursor {
+			rest[name] = v
+		}
+	}
+	var b strings.Builder
+	for _, o := range order {
+		if o.Desc {
+			b.WriteByte('-')
+		}
+		b.WriteString(o.Field + ",")
+	}
+	sum := sha256.Sum256([]byte(b.String() + "?" + rest.Encode()))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,87 @@
+package listquery
+
+import (
+	"strconv"
+	"strings"
+)
+
+//nolint:gochecknoglobals //SQL operators of filter operators
+var sqlOps = map[string]string{
+	OpEq:  "=",
+	OpNe:  "<>",
+	OpLt:  "<",
+	OpLte: "<=",
+	OpGt:  ">",
+	OpGte: ">=",
+}
+
+// Build appends the filters, the cursor condition, ORDER BY and LIMIT to query. where holds the conditions
+// of the caller with placeholders of args. Values are always placeholders and columns come from the spec.
+// Limit+1 rows are fetched, so Page knows if there is a next page.
+func (q Query) Build(query string, where []string, args []any) (string, []any) {
+	conds := append([]string(nil), where...)
+	args = append([]any(nil), args...)
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	for _, f := range q.Filters {
+		column := q.spec.Fields[f.Field].Column
+		switch f.Op {
+		case OpIn:
+			placeholders := make([]string, 0, len(f.Values))
+			for _, v := range f.Values {
+				placeholders = append(placeholders, arg(v))
+			}
+			conds = append(conds, column+" IN ("+strings.Join(placeholders, ", ")+")")
+		case OpPrefix:
+			s, _ := f.Values[0].(string)
+			conds = append(conds, column+" LIKE "+arg(escapeLike(s)+"%"))
+		default:
+			conds = append(conds, column+" "+sqlOps[f.Op]+" "+arg(f.Values[0]))
+		}
+	}
+
+	// keyset condition: (a > $1) OR (a = $1 AND b > $2) OR ...
+	if len(q.After) == len(q.Order) && len(q.After) > 0 {
+		placeholders := make([]string, len(q.After))
+		for i, v := range q.After {
+			placeholders[i] = arg(v)
+		}
+		alternatives := make([]string, 0, len(q.Order))
+		for i, o := range q.Order {
+			parts := make([]string, 0, i+1)
+			for j := range i {
+				parts = append(parts, q.spec.Fields[q.Order[j].Field].Column+" = "+placeholders[j])
+			}
+			op := " > "
+			if o.Desc {
+				op = " < "
+			}
+			parts = append(parts, q.spec.Fields[o.Field].Column+op+placeholders[i])
+			alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+		}
+		conds = append(conds, "("+strings.Join(alternatives, " OR ")+")")
+	}
+
+	if len(conds) > 0 {
+		query += "\nWHERE " + strings.Join(conds, " AND ")
+	}
+	order := make([]string, 0, len(q.Order))
+	for _, o := range q.Order {
+		dir := " ASC"
+		if o.Desc {
+			dir = " DESC"
+		}
+		order = append(order, q.spec.Fields[o.Field].Column+dir)
+	}
+	query += "\nORDER BY " + strings.Join(order, ", ")
+	query += "\nLIMIT " + arg(q.Limit+1)
+	return query, args
+}
+
+// escapeLike escapes the wildcards of LIKE, backslash is the default escape character of postgres
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// HeaderRequestID carries the request ID between services
+const HeaderRequestID = "X-Request-ID"
+
+const maxRequestIDLen = 128
+
+type contextKey string
+
+const contextKeyRequestID contextKey = "requestID"
+
+func ContextWithRequestID(ctx context.Context, reqID string) context.Context {
+	return context.WithValue(ctx, contextKeyRequestID, reqID)
+}
+
+// RequestIDFromContext returns the ID of the request ctx belongs to or empty string
+func RequestIDFromContext(ctx context.Context) string {
+	reqID, _ := ctx.Value(contextKeyRequestID).(string)
+	return reqID
+}
+
+func NewRequestID() string {
+	return uuid.NewString()
+}
+
+// ValidRequestID accepts IDs of letters, digits and "-_.:" up to 128 bytes,
+// other IDs from clients are replaced so they can't break logs, headers or SQL comments
+func ValidRequestID(reqID string) bool {
+	if reqID == "" || len(reqID) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range reqID {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"go-clean-template/config"
 	"go-clean-template/pkg/logger/common"
@@ -22,6 +23,8 @@ type LoggerOpts struct {
 }
 
 type Logger interface {
+	// WithContext returns a logger that adds the request ID of ctx to every message
+	WithContext(ctx context.Context) Logger
 	Debug(v ...interface{})
 	Info(v ...interface{})
 	Warning(v ...interface{})
@@ -31,8 +34,19 @@ type Logger interface {
 	Close()
 }
 
+// sink is a logger of one output, reqID is empty outside of requests
+type sink interface {
+	Debug(reqID, msg string)
+	Info(reqID, msg string)
+	Warning(reqID, msg string)
+	Error(reqID, msg string)
+	Fatal(reqID, msg string)
+	Close()
+}
+
 type logger struct {
-	lgs []Logger
+	lgs   []sink
+	reqID string
 }
 
 func New(opts *LoggerOpts) Logger {
@@ -49,6 +63,13 @@ func New(opts *LoggerOpts) Logger {
 	return l
 }
 
+func (l *logger) WithContext(ctx context.Context) Logger {
+	return &logger{
+		lgs:   l.lgs,
+		reqID: RequestIDFromContext(ctx),
+	}
+}
+
 func (l *logger) Close() {
 	for i := range l.lgs {
 		if l.lgs[i] != nil {
@@ -61,7 +82,7 @@ func (l *logger) Debug(v ...interface{}) {
 	msg := concat(v...)
 	for i := range l.lgs {
 		if l.lgs[i] != nil {
-			l.lgs[i].Debug(msg)
+			l.lgs[i].Debug(l.reqID, msg)
 		}
 	}
 }
@@ -70,7 +91,7 @@ func (l *logger) Info(v ...interface{}) {
 	msg := concat(v...)
 	for i := range l.lgs {
 		if l.lgs[i] != nil {
-			l.lgs[i].Info(msg)
+			l.lgs[i].Info(l.reqID, msg)
 		}
 	}
 }
@@ -79,7 +100,7 @@ func (l *logger) Warning(v ...interface{}) {
 	msg := concat(v...)
 	for i := range l.lgs {
 		if l.lgs[i] != nil {
-			l.lgs[i].Warning(msg)
+			l.lgs[i].Warning(l.reqID, msg)
 		}
 	}
 }
@@ -88,7 +109,7 @@ func (l *logger) Error(v ...interface{}) {
 	msg := concat(v...)
 	for i := range l.lgs {
 		if l.lgs[i] != nil {
-			l.lgs[i].Error(msg)
+			l.lgs[i].Error(l.reqID, msg)
 		}
 	}
 }
@@ -97,7 +118,7 @@ func (l *logger) Fatal(v ...interface{}) {
 	msg := concat(v...)
 	for i := range l.lgs {
 		if l.lgs[i] != nil {
-			l.lgs[i].Fatal(msg)
+			l.lgs[i].Fatal(l.reqID, msg)
 		}
 	}
 	time.Sleep(2 * time.Second)
@@ -52,22 +52,29 @@ func NewLogger(selfOpts *SlogLoggerOpts, opts *common.GeneralOpts) *Logger {
 
 func (l *Logger) Close() {}
 
-func (l *Logger) Debug(v ...interface{}) {
-	l.logger.Debug(v[0].(string), "caller", common.GetFuncName())
+func (l *Logger) Debug(reqID, msg string) {
+	l.logger.Debug(msg, append(requestIDAttr(reqID), "caller", common.GetFuncName())...)
 }
 
-func (l *Logger) Info(v ...interface{}) {
-	l.logger.Info(v[0].(string))
+func (l *Logger) Info(reqID, msg string) {
+	l.logger.Info(msg, requestIDAttr(reqID)...)
 }
 
-func (l *Logger) Warning(v ...interface{}) {
-	l.logger.Warn(v[0].(string))
+func (l *Logger) Warning(reqID, msg string) {
+	l.logger.Warn(msg, requestIDAttr(reqID)...)
 }
 
-func (l *Logger) Error(v ...interface{}) {
-	l.logger.Error(v[0].(string), "caller", common.GetFuncName())
+func (l *Logger) Error(reqID, msg string) {
+	l.logger.Error(msg, append(requestIDAttr(reqID), "caller", common.GetFuncName())...)
 }
 
-func (l *Logger) Fatal(v ...interface{}) {
-	l.logger.Error(v[0].(string))
+func (l *Logger) Fatal(reqID, msg string) {
+	l.logger.Error(msg, requestIDAttr(reqID)...)
+}
+
+func requestIDAttr(reqID string) []any {
+	if reqID == "" {
+		return []any{}
+	}
+	return []any{"request_id", reqID}
 }
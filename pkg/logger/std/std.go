@@ -90,40 +90,42 @@ func NewLogger(selfOpts *StdLoggerOpts, opts *common.GeneralOpts) *Logger {
 
 func (l *Logger) Close() {}
 
-func (l *Logger) Debug(v ...interface{}) {
+func (l *Logger) Debug(reqID, msg string) {
 	now := time.Now().Format(dtMask)
 	if l.level >= DEBUG {
-		msg, _ := v[0].(string)
-		l.logger.Print(now+" DEBUG ", common.GetFuncName(), " ", msg)
+		l.logger.Print(now+" DEBUG ", common.GetFuncName(), " ", withRequestID(reqID, msg))
 	}
 }
 
-func (l *Logger) Info(v ...interface{}) {
+func (l *Logger) Info(reqID, msg string) {
 	now := time.Now().Format(dtMask)
 	if l.level >= INFO {
-		msg, _ := v[0].(string)
-		l.logger.Print(now+" INFO ", common.GetFuncName(), " ", msg)
+		l.logger.Print(now+" INFO ", common.GetFuncName(), " ", withRequestID(reqID, msg))
 	}
 }
 
-func (l *Logger) Warning(v ...interface{}) {
+func (l *Logger) Warning(reqID, msg string) {
 	now := time.Now().Format(dtMask)
 	if l.level >= WARNING {
-		msg, _ := v[0].(string)
-		l.logger.Print(now+" WARNING ", common.GetFuncName(), " ", msg)
+		l.logger.Print(now+" WARNING ", common.GetFuncName(), " ", withRequestID(reqID, msg))
 	}
 }
 
-func (l *Logger) Error(v ...interface{}) {
+func (l *Logger) Error(reqID, msg string) {
 	now := time.Now().Format(dtMask)
 	if l.level >= ERROR {
-		msg, _ := v[0].(string)
-		l.logger.Print(now+" ERROR ", common.GetFuncName(), " ", msg)
+		l.logger.Print(now+" ERROR ", common.GetFuncName(), " ", withRequestID(reqID, msg))
 	}
 }
 
-func (l *Logger) Fatal(v ...interface{}) {
+func (l *Logger) Fatal(reqID, msg string) {
 	now := time.Now().Format(dtMask)
-	msg, _ := v[0].(string)
-	l.logger.Print(now+" FATAL ", common.GetFuncName(), " ", msg)
+	l.logger.Print(now+" FATAL ", common.GetFuncName(), " ", withRequestID(reqID, msg))
+}
+
+func withRequestID(reqID, msg string) string {
+	if reqID == "" {
+		return msg
+	}
+	return reqID + " " + msg
 }
@@ -4,12 +4,10 @@ import (
 	"bytes"
 	"fmt"
 	"go-clean-template/pkg/logger/common"
-	"strings"
 	"sync"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
-	"github.com/google/uuid"
 )
 
 const dtMask = time.RFC3339Nano
@@ -132,93 +130,59 @@ func (l *Logger) Close() {
 	close(l.ch)
 }
 
-func (l *Logger) Debug(v ...interface{}) {
-	ss := strings.Split(v[0].(string), " ")
-	if _, err := uuid.Parse(ss[0]); err == nil {
-		logMsg := fmt.Sprintf("%s DEBUG %s %s", time.Now().Format(dtMask), common.GetFuncName(),
-			"["+strings.ReplaceAll(v[0].(string), ss[0]+" ", "")+"]")
-		l.logs.Store(ss[0], logMsg)
-
-		go func() {
-			time.Sleep(30 * time.Second) //nolint:mnd //timeout 30s
-			l.logs.Delete(ss[0])
-		}()
-	}
+// Debug, Info and Warning are kept as the log stack of the request and sent with its error
+func (l *Logger) Debug(reqID, msg string) {
+	l.stack(reqID, "DEBUG", common.GetFuncName(), msg)
 }
 
-func (l *Logger) Info(v ...interface{}) {
-	ss := strings.Split(v[0].(string), " ")
-	if _, err := uuid.Parse(ss[0]); err == nil {
-		logMsg := fmt.Sprintf("%s INFO %s %s", time.Now().Format(dtMask), common.GetFuncName(),
-			"["+strings.ReplaceAll(v[0].(string), ss[0]+" ", "")+"]")
-		l.logs.Store(ss[0], logMsg)
+func (l *Logger) Info(reqID, msg string) {
+	l.stack(reqID, "INFO", common.GetFuncName(), msg)
+}
 
-		go func() {
-			time.Sleep(30 * time.Second) //nolint:mnd //timeout 30s
-			l.logs.Delete(ss[0])
-		}()
-	}
+func (l *Logger) Warning(reqID, msg string) {
+	l.stack(reqID, "WARNING", common.GetFuncName(), msg)
 }
 
-func (l *Logger) Warning(v ...interface{}) {
-	ss := strings.Split(v[0].(string), " ")
-	if _, err := uuid.Parse(ss[0]); err == nil {
-		logMsg := fmt.Sprintf("%s WARNING %s %s", time.Now().Format(dtMask), common.GetFuncName(),
-			"["+strings.ReplaceAll(v[0].(string), ss[0]+" ", "")+"]")
-		l.logs.Store(ss[0], logMsg)
+func (l *Logger) Error(reqID, msg string) {
+	l.send(reqID, "ERROR", common.GetFuncName(), msg)
+}
 
-		go func() {
-			time.Sleep(30 * time.Second) //nolint:mnd //timeout 30s
-			l.logs.Delete(ss[0])
-		}()
-	}
+func (l *Logger) Fatal(reqID, msg string) {
+	l.send(reqID, "FATAL", common.GetFuncName(), msg)
 }
 
-func (l *Logger) Error(v ...interface{}) {
-	ss := strings.Split(v[0].(string), " ")
-	if _, err := uuid.Parse(ss[0]); err == nil {
-		logMsg := fmt.Sprintf("%s ERROR %s %s", time.Now().Format(dtMask), common.GetFuncName(),
-			"["+strings.ReplaceAll(v[0].(string), ss[0]+" ", "")+"]")
-		l.logs.Store(ss[0], logMsg)
-
-		go func() {
-			time.Sleep(time.Second)
-			if logs, ok := l.logs.Load(ss[0]); ok {
-				msg := NewMessage("ERROR", l.appName, l.version, l.env, l.instanceID, ss[0], logs)
-				l.ch <- msg.ToString()
-			}
-		}()
-
-		go func() {
-			time.Sleep(30 * time.Second) //nolint:mnd //timeout 30s
-			l.logs.Delete(ss[0])
-		}()
-	} else {
-		logMsg := fmt.Sprintf("%s ERROR %s %s", time.Now().Format(dtMask), common.GetFuncName(), "["+v[0].(string)+"]")
-		msg := NewMessage("ERROR", l.appName, l.version, l.env, l.instanceID, "", []string{logMsg})
-		l.ch <- msg.ToString()
+func (l *Logger) stack(reqID, level, caller, msg string) {
+	if reqID == "" {
+		return
 	}
+	l.logs.Store(reqID, fmt.Sprintf("%s %s %s %s", time.Now().Format(dtMask), level, caller, "["+msg+"]"))
+
+	go func() {
+		time.Sleep(30 * time.Second) //nolint:mnd //timeout 30s
+		l.logs.Delete(reqID)
+	}()
 }
 
-func (l *Logger) Fatal(v ...interface{}) {
-	ss := strings.Split(v[0].(string), " ")
-	if _, err := uuid.Parse(ss[0]); err == nil {
-		logMsg := fmt.Sprintf("%s FATAL %s %s", time.Now().Format(dtMask), common.GetFuncName(),
-			"["+strings.ReplaceAll(v[0].(string), ss[0]+" ", "")+"]")
-		l.logs.Store(ss[0], logMsg)
-
-		go func() {
-			time.Sleep(time.Second)
-			if logs, ok := l.logs.Load(ss[0]); ok {
-				msg := NewMessage("FATAL", l.appName, l.version, l.env, l.instanceID, ss[0], logs)
-				l.ch <- msg.ToString()
-			}
-		}()
-	} else {
-		logMsg := fmt.Sprintf("%s FATAL %s %s", time.Now().Format(dtMask), common.GetFuncName(), "["+v[0].(string)+"]")
-		msg := NewMessage("FATAL", l.appName, l.version, l.env, l.instanceID, "", []string{logMsg})
-		l.ch <- msg.ToString()
+func (l *Logger) send(reqID, level, caller, msg string) {
+	logMsg := fmt.Sprintf("%s %s %s %s", time.Now().Format(dtMask), level, caller, "["+msg+"]")
+	if reqID == "" {
+		l.ch <- NewMessage(level, l.appName, l.version, l.env, l.instanceID, "", []string{logMsg}).ToString()
+		return
 	}
+	l.logs.Store(reqID, logMsg)
+
+	// later messages of the request are collected into the same stack
+	go func() {
+		time.Sleep(time.Second)
+		if logs, ok := l.logs.Load(reqID); ok {
+			l.ch <- NewMessage(level, l.appName, l.version, l.env, l.instanceID, reqID, logs).ToString()
+		}
+	}()
+
+	go func() {
+		time.Sleep(30 * time.Second) //nolint:mnd //timeout 30s
+		l.logs.Delete(reqID)
+	}()
 }
 
 func (l *Logger) senderToChat() {
@@ -17,6 +17,7 @@ type monitoring struct {
 
 	histograms map[string]*prometheus.HistogramVec
 	counters   map[string]*prometheus.CounterVec
+	gauges     map[string]*prometheus.GaugeVec
 	prefix     string
 }
 
@@ -25,6 +26,8 @@ type Monitoring interface {
 	Observe(packageName string, method string, value float64)
 	Count(packageName string, method string, fail bool)
 	Add(packageName string, method string, value int64)
+	RegisterGauge(name string)
+	Set(name string, method string, value float64)
 
 	GetMetricsHandler() http.Handler
 	WrapHandler(path string, h http.Handler) http.Handler
@@ -34,6 +37,7 @@ func New(prefix string) *monitoring {
 	m := &monitoring{}
 	m.histograms = make(map[string]*prometheus.HistogramVec)
 	m.counters = make(map[string]*prometheus.CounterVec)
+	m.gauges = make(map[string]*prometheus.GaugeVec)
 	m.prefix = prefix
 
 	m.reqs = promauto.NewCounterVec(
@@ -105,6 +109,22 @@ func (m *monitoring) Register(packageName string) {
 	m.counters[packageName] = kafkaReqs
 }
 
+func (m *monitoring) RegisterGauge(name string) {
+	m.gauges[name] = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: fmt.Sprintf("%s_%s", m.prefix, name),
+			Help: "Current value of " + name + ", partitioned by method",
+		},
+		[]string{"method"},
+	)
+}
+
+func (m *monitoring) Set(name string, method string, value float64) {
+	if gauge, ok := m.gauges[name]; ok {
+		gauge.With(prometheus.Labels{"method": method}).Set(value)
+	}
+}
+
 func (m *monitoring) Reqs() *prometheus.CounterVec {
 	return m.reqs
 }
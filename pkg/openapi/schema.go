@@ -0,0 +1,254 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-clean-template/pkg/validate"
+
+	"github.com/google/uuid"
+)
+
+const refPrefix = "#/components/schemas/"
+
+//nolint:gochecknoglobals //reflected types
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	uuidType            = reflect.TypeOf(uuid.UUID{})
+	rawMessageType      = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+	genericArgs = regexp.MustCompile(`[\w\-./]+\.|[\[\],* ]`)
+)
+
+// Schema returns the schema of values of t, named structs are added to components and referenced
+func (d *Document) Schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "nanoseconds"}
+	case t == uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case t == rawMessageType:
+		return &Schema{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() { //nolint:exhaustive //other kinds are not marshaled
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.Schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.Schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.object(t, false)
+		}
+		return &Schema{Ref: refPrefix + d.component(t)}
+	}
+	return &Schema{}
+}
+
+// Body returns the schema of the JSON fields of a request DTO, nil if it has none.
+// Fields bound from path, query and headers are not a part of the body.
+func (d *Document) Body(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return d.Schema(t)
+	}
+	s := d.object(t, true)
+	if len(s.Properties) == 0 {
+		return nil
+	}
+	return s
+}
+
+// Parameters returns the path, query and header parameters of a request DTO
+func (d *Document) Parameters(t reflect.Type) []Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var params []Parameter
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			params = append(params, d.Parameters(f.Type)...)
+			continue
+		}
+		for _, in := range []string{InPath, InQuery, InHeader} {
+			name, _, _ := strings.Cut(f.Tag.Get(in), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			s := d.Schema(f.Type)
+			if s.Format == "date-time" {
+				// binding accepts dates in parameters too
+				s = &Schema{Type: "string", Description: "date-time in RFC 3339 or a date"}
+			}
+			required := applyRules(s, f.Tag.Get("validate"))
+			params = append(params, Parameter{Name: name, In: in, Required: required || in == InPath, Schema: s})
+		}
+	}
+	return params
+}
+
+// component registers the schema of a named struct once, recursive types refer to themselves
+func (d *Document) component(t reflect.Type) string {
+	if name, ok := d.types[t]; ok {
+		return name
+	}
+	// instances of generic types are named after their type arguments: Result[pkg/domain.Job] is ResultJob
+	name := genericArgs.ReplaceAllString(t.Name(), "")
+	if _, taken := d.Components.Schemas[name]; taken {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+	d.types[t] = name
+	d.Components.Schemas[name] = &Schema{}
+	*d.Components.Schemas[name] = *d.object(t, false)
+	return name
+}
+
+func (d *Document) object(t reflect.Type, body bool) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if body && (f.Tag.Get(InPath) != "" || f.Tag.Get(InQuery) != "" || f.Tag.Get(InHeader) != "") {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			embedded := d.object(f.Type, body)
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fs := d.Schema(f.Type)
+		if applyRules(fs, f.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = fs
+	}
+	return s
+}
+
+// applyRules puts the rules of a "validate" tag into s and returns true if the field is required.
+// Rules of slices apply to their items like in pkg/validate, except min and max.
+func applyRules(s *Schema, tag string) bool {
+	required := false
+	for _, r := range validate.Rules(tag) {
+		target := s
+		if s.Items != nil && r.Name != validate.RuleMin && r.Name != validate.RuleMax {
+			target = s.Items
+		}
+		switch r.Name {
+		case validate.RuleRequired:
+			required = true
+		case validate.RuleMin, validate.RuleMax:
+			applyRange(target, r)
+		case validate.RuleOneOf:
+			for _, v := range strings.Split(r.Param, "|") {
+				target.Enum = append(target.Enum, enumValue(target, v))
+			}
+		case validate.RuleDate:
+			switch r.Param {
+			case "", time.DateOnly:
+				target.Format = "date"
+			case time.RFC3339:
+				target.Format = "date-time"
+			default:
+				target.Description = "date in format " + r.Param
+			}
+		case validate.RuleRegex:
+			target.Pattern = r.Param
+		}
+	}
+	return required
+}
+
+func applyRange(s *Schema, r validate.Rule) {
+	limit, err := strconv.ParseFloat(r.Param, 64)
+	if err != nil {
+		return
+	}
+	n := int(limit)
+	switch {
+	case s.Type == "integer" || s.Type == "number":
+		if r.Name == validate.RuleMin {
+			s.Minimum = &limit
+		} else {
+			s.Maximum = &limit
+		}
+	case s.Type == "array":
+		if r.Name == validate.RuleMin {
+			s.MinItems = &n
+		} else {
+			s.MaxItems = &n
+		}
+	case s.Type == "string":
+		if r.Name == validate.RuleMin {
+			s.MinLength = &n
+		} else {
+			s.MaxLength = &n
+		}
+	}
+}
+
+func enumValue(s *Schema, v string) any {
+	switch s.Type {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return v
+}
@@ -0,0 +1,165 @@
+// Package openapi builds OpenAPI 3.0 documents from Go types and validates requests and responses against them.
+// Schemas follow encoding/json and the "validate" tags of pkg/validate, parameters follow the
+// "path", "query" and "header" tags of the binding package.
+package openapi
+
+import (
+	"reflect"
+	"strings"
+)
+
+const (
+	Version = "3.0.3"
+
+	InPath   = "path"
+	InQuery  = "query"
+	InHeader = "header"
+
+	ContentTypeJSON = "application/json"
+)
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+	// types are the names of the component schemas of named structs
+	types map[reflect.Type]string
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+	Patch  *Operation `json:"patch,omitempty"`
+}
+
+type Operation struct {
+	OperationID string                `json:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+func New(title, version string) *Document {
+	return &Document{
+		OpenAPI:    Version,
+		Info:       Info{Title: title, Version: version},
+		Paths:      make(map[string]*PathItem),
+		Components: Components{Schemas: make(map[string]*Schema)},
+		types:      make(map[reflect.Type]string),
+	}
+}
+
+// AddOperation puts op under the path template and method, methods without a field in PathItem are ignored
+func (d *Document) AddOperation(method, path string, op *Operation) {
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	if slot := item.slot(method); slot != nil {
+		*slot = op
+	}
+}
+
+// Operation returns the operation of the method and path template, nil if it is not documented
+func (d *Document) Operation(method, path string) *Operation {
+	item, ok := d.Paths[path]
+	if !ok {
+		return nil
+	}
+	if slot := item.slot(method); slot != nil {
+		return *slot
+	}
+	return nil
+}
+
+func (p *PathItem) slot(method string) **Operation {
+	switch strings.ToUpper(method) {
+	case "GET":
+		return &p.Get
+	case "PUT":
+		return &p.Put
+	case "POST":
+		return &p.Post
+	case "DELETE":
+		return &p.Delete
+	case "PATCH":
+		return &p.Patch
+	}
+	return nil
+}
+
+// resolve follows $ref to a component schema
+func (d *Document) resolve(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		s = d.Components.Schemas[strings.TrimPrefix(s.Ref, refPrefix)]
+	}
+	return s
+}
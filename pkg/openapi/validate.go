@@ -0,0 +1,228 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-clean-template/pkg/validate"
+
+	"github.com/google/uuid"
+)
+
+// ValidateParams checks the parameters of op, values returns the raw values of a parameter.
+// Violations are reported with the rules of pkg/validate, so they are mapped like DTO validation errors.
+func (d *Document) ValidateParams(op *Operation, values func(in, name string) []string) []validate.FieldError {
+	var errs []validate.FieldError
+	for _, p := range op.Parameters {
+		raw := values(p.In, p.Name)
+		if len(raw) == 0 || raw[0] == "" {
+			if p.Required {
+				errs = append(errs, validate.FieldError{Field: p.Name, Rule: validate.RuleRequired, Message: "is required"})
+			}
+			continue
+		}
+		s := d.resolve(p.Schema)
+		if s.Type == "array" {
+			var items []any
+			for _, v := range raw {
+				for _, part := range strings.Split(v, ",") {
+					items = append(items, parseParam(d.resolve(s.Items), part))
+				}
+			}
+			errs = append(errs, d.validateValue(s, items, p.Name)...)
+			continue
+		}
+		errs = append(errs, d.validateValue(s, parseParam(s, raw[0]), p.Name)...)
+	}
+	return errs
+}
+
+// parseParam converts a raw parameter to the JSON type of the schema, values that can't be converted stay strings
+func parseParam(s *Schema, v string) any {
+	if s == nil {
+		return v
+	}
+	switch s.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return json.Number(v)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// ValidateJSON checks a JSON document against s, field is the name of its root in errors
+func (d *Document) ValidateJSON(s *Schema, body []byte, field string) []validate.FieldError {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return []validate.FieldError{typeError(field, "JSON")}
+	}
+	return d.validateValue(s, v, field)
+}
+
+func (d *Document) validateValue(s *Schema, v any, field string) []validate.FieldError {
+	s = d.resolve(s)
+	if s == nil || v == nil {
+		return nil
+	}
+	switch s.Type {
+	case "object":
+		return d.validateObject(s, v, field)
+	case "array":
+		items, ok := v.([]any)
+		if !ok {
+			return []validate.FieldError{typeError(field, "array")}
+		}
+		errs := checkLen(s.MinItems, s.MaxItems, len(items), field)
+		for i, item := range items {
+			errs = append(errs, d.validateValue(s.Items, item, fmt.Sprintf("%s[%d]", field, i))...)
+		}
+		return errs
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return []validate.FieldError{typeError(field, "string")}
+		}
+		return validateString(s, str, field)
+	case "integer", "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			return []validate.FieldError{typeError(field, s.Type)}
+		}
+		return validateNumber(s, n, field)
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return []validate.FieldError{typeError(field, "boolean")}
+		}
+	}
+	return nil
+}
+
+func (d *Document) validateObject(s *Schema, v any, field string) []validate.FieldError {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return []validate.FieldError{typeError(field, "object")}
+	}
+	prefix := ""
+	if field != "" {
+		prefix = field + "."
+	}
+	var errs []validate.FieldError
+	for _, name := range s.Required {
+		if obj[name] == nil {
+			errs = append(errs, validate.FieldError{Field: prefix + name, Rule: validate.RuleRequired,
+				Message: "is required"})
+		}
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		ps, ok := s.Properties[k]
+		if !ok {
+			ps = s.AdditionalProperties
+		}
+		errs = append(errs, d.validateValue(ps, obj[k], prefix+k)...)
+	}
+	return errs
+}
+
+func validateString(s *Schema, v, field string) []validate.FieldError {
+	errs := checkLen(s.MinLength, s.MaxLength, len([]rune(v)), field)
+	if len(s.Enum) > 0 && !slices.Contains(s.Enum, any(v)) {
+		errs = append(errs, enumError(s, field))
+	}
+	switch s.Format {
+	case "date":
+		if _, err := time.Parse(time.DateOnly, v); err != nil {
+			errs = append(errs, validate.FieldError{Field: field, Rule: validate.RuleDate, Param: time.DateOnly,
+				Message: "must be a date in format " + time.DateOnly})
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, v); err != nil {
+			errs = append(errs, validate.FieldError{Field: field, Rule: validate.RuleDate, Param: time.RFC3339,
+				Message: "must be a date in format " + time.RFC3339})
+		}
+	case "uuid":
+		if _, err := uuid.Parse(v); err != nil {
+			errs = append(errs, typeError(field, "uuid"))
+		}
+	}
+	if s.Pattern != "" {
+		if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(v) {
+			errs = append(errs, validate.FieldError{Field: field, Rule: validate.RuleRegex, Param: s.Pattern,
+				Message: "must match " + s.Pattern})
+		}
+	}
+	return errs
+}
+
+func validateNumber(s *Schema, n json.Number, field string) []validate.FieldError {
+	if s.Type == "integer" {
+		if _, err := n.Int64(); err != nil {
+			return []validate.FieldError{typeError(field, "integer")}
+		}
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return []validate.FieldError{typeError(field, s.Type)}
+	}
+	var errs []validate.FieldError
+	if s.Minimum != nil && f < *s.Minimum {
+		errs = append(errs, rangeError(validate.RuleMin, *s.Minimum, field))
+	}
+	if s.Maximum != nil && f > *s.Maximum {
+		errs = append(errs, rangeError(validate.RuleMax, *s.Maximum, field))
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return fmt.Sprint(e) == n.String() }) {
+		errs = append(errs, enumError(s, field))
+	}
+	return errs
+}
+
+func checkLen(minLen, maxLen *int, n int, field string) []validate.FieldError {
+	var errs []validate.FieldError
+	if minLen != nil && n < *minLen {
+		errs = append(errs, rangeError(validate.RuleMin, float64(*minLen), field))
+	}
+	if maxLen != nil && n > *maxLen {
+		errs = append(errs, rangeError(validate.RuleMax, float64(*maxLen), field))
+	}
+	return errs
+}
+
+func rangeError(rule string, limit float64, field string) validate.FieldError {
+	param := strconv.FormatFloat(limit, 'f', -1, 64)
+	what := "at least "
+	if rule == validate.RuleMax {
+		what = "at most "
+	}
+	return validate.FieldError{Field: field, Rule: rule, Param: param, Message: "must be " + what + param}
+}
+
+func enumError(s *Schema, field string) validate.FieldError {
+	options := make([]string, 0, len(s.Enum))
+	for _, e := range s.Enum {
+		options = append(options, fmt.Sprint(e))
+	}
+	return validate.FieldError{Field: field, Rule: validate.RuleOneOf, Param: strings.Join(options, "|"),
+		Message: "must be one of " + strings.Join(options, ", ")}
+}
+
+func typeError(field, typ string) validate.FieldError {
+	return validate.FieldError{Field: field, Rule: validate.RuleType, Param: typ, Message: "must be " + typ}
+}
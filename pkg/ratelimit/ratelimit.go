@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+// Limit allows Burst requests at once and refills Rate tokens per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Per returns the limit of n requests per period with burst n
+func Per(n int, period time.Duration) Limit {
+	return Limit{Rate: float64(n) / period.Seconds(), Burst: n}
+}
+
+// Window is the time an empty bucket takes to refill
+func (l Limit) Window() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, zero if this one is allowed
+	RetryAfter time.Duration
+}
+
+// NewResult describes a bucket left with tokens after the decision
+func NewResult(l Limit, tokens float64, allowed bool) Result {
+	res := Result{
+		Allowed:   allowed,
+		Limit:     l.Burst,
+		Remaining: max(0, int(math.Floor(tokens))),
+		Reset:     time.Duration((float64(l.Burst) - tokens) / l.Rate * float64(time.Second)),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) / l.Rate * float64(time.Second))
+	}
+	return res
+}
+
+// Store takes a token from the bucket of key
+type Store interface {
+	Take(ctx context.Context, key string, l Limit) (Result, error)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	window  time.Duration
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewMemoryStore keeps buckets of this process, full buckets are dropped
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *memoryStore) Take(_ context.Context, key string, l Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.Burst), b.tokens+now.Sub(b.updated).Seconds()*l.Rate)
+	b.updated = now
+	b.window = l.Window()
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return NewResult(l, b.tokens, allowed), nil
+}
+
+// sweep drops buckets refilled since their last request, they are equal to new ones
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if now.Sub(b.updated) > b.window {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Package sdlisten takes listeners passed by systemd socket activation (sd_listen_fds).
+package sdlisten
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFdsStart is SD_LISTEN_FDS_START, the first passed descriptor
+const listenFdsStart = 3
+
+type Listener struct {
+	// Name is from FileDescriptorName= of the socket unit, systemd defaults it to the unit name
+	Name string
+	net.Listener
+}
+
+// Listeners returns the sockets passed to this process and unsets the LISTEN_* variables,
+// so they are not inherited by children. It returns nil if the process is not socket activated.
+func Listeners() ([]Listener, error) {
+	const op = "sdlisten.Listeners"
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	for _, env := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		_ = os.Unsetenv(env)
+	}
+
+	res := make([]Listener, 0, n)
+	for i := range n {
+		fd := listenFdsStart + i
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		f := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(f)
+		// FileListener dups the descriptor
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: fd %d (%s): %w", op, fd, name, err)
+		}
+		res = append(res, Listener{name, ln})
+	}
+	return res, nil
+}
@@ -0,0 +1,149 @@
+// Package tlsreload keeps a TLS certificate and a client CA bundle in sync with files on disk.
+// Files are polled by modification time, a failed reload keeps the previous material.
+package tlsreload
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"go-clean-template/pkg/logger"
+	"os"
+	"sync"
+	"time"
+)
+
+type material struct {
+	cert    *tls.Certificate
+	cas     *x509.CertPool
+	modTime time.Time
+}
+
+type reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	interval time.Duration
+
+	mu  sync.RWMutex
+	cur material
+	lg  logger.Logger
+}
+
+// New loads the files once, caFile may be empty if client certificates are not verified
+func New(certFile, keyFile, caFile string, interval time.Duration, lg logger.Logger) (*reloader, error) {
+	r := &reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		interval: interval,
+		lg:       lg,
+	}
+	m, err := r.load()
+	if err != nil {
+		return nil, fmt.Errorf("tlsreload.New: %w", err)
+	}
+	r.cur = m
+	return r, nil
+}
+
+// GetCertificate is meant for tls.Config.GetCertificate
+func (r *reloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cur.cert, nil
+}
+
+// ClientCAs returns the current client CA bundle, nil if caFile is not set
+func (r *reloader) ClientCAs() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cur.cas
+}
+
+// Run polls the files until ctx is done
+func (r *reloader) Run(ctx context.Context) {
+	if r.interval <= 0 {
+		return
+	}
+	t := time.NewTicker(r.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			r.reload()
+		}
+	}
+}
+
+func (r *reloader) reload() {
+	modTime, err := r.modTime()
+	if err != nil {
+		r.lg.Error(fmt.Errorf("tlsreload: %w", err))
+		return
+	}
+	r.mu.RLock()
+	changed := modTime.After(r.cur.modTime)
+	r.mu.RUnlock()
+	if !changed {
+		return
+	}
+
+	m, err := r.load()
+	if err != nil {
+		r.lg.Error(fmt.Errorf("tlsreload: keeping previous certificate: %w", err))
+		return
+	}
+	r.mu.Lock()
+	r.cur = m
+	r.mu.Unlock()
+	r.lg.Info(fmt.Sprintf("tlsreload: certificate %s reloaded", r.certFile))
+}
+
+func (r *reloader) load() (material, error) {
+	modTime, err := r.modTime()
+	if err != nil {
+		return material{}, err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return material{}, fmt.Errorf("tls.LoadX509KeyPair: %w", err)
+	}
+
+	var cas *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return material{}, fmt.Errorf("os.ReadFile: %w", err)
+		}
+		cas = x509.NewCertPool()
+		if !cas.AppendCertsFromPEM(pem) {
+			return material{}, errors.New("no certificates in " + r.caFile)
+		}
+	}
+
+	return material{&cert, cas, modTime}, nil
+}
+
+// modTime is the latest modification time of the files
+func (r *reloader) modTime() (time.Time, error) {
+	var latest time.Time
+	for _, f := range []string{r.certFile, r.keyFile, r.caFile} {
+		if f == "" {
+			continue
+		}
+		st, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("os.Stat: %w", err)
+		}
+		if st.ModTime().After(latest) {
+			latest = st.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,244 @@
+// Package validate checks struct fields against rules declared in the "validate" tag:
+//
+//	Limit int    `query:"limit" validate:"min=1,max=1000"`
+//	Sort  string `query:"sort"  validate:"oneof=asc|desc"`
+//	Date  string `query:"date"  validate:"required,date=2006-01-02"`
+//	Code  string `json:"code"   validate:"regex=^[A-Z]{3}$"`
+//
+// Rules are separated by commas, regex must be the last rule because the pattern may contain commas.
+// All rules except required are skipped for absent values: nil pointers and empty strings, slices and maps.
+// Numbers are never absent, an optional number has to be a pointer.
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	RuleRequired = "required"
+	RuleMin      = "min"
+	RuleMax      = "max"
+	RuleOneOf    = "oneof"
+	RuleDate     = "date"
+	RuleRegex    = "regex"
+	// RuleType is reported for values of a wrong type, it can't be used in tags
+	RuleType = "type"
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+var regexps sync.Map //nolint:gochecknoglobals //compiled patterns are shared between calls
+
+// Struct validates exported fields of the struct v points to, nested structs are validated recursively
+func Struct(v any) []FieldError {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	return validateStruct(rv, "")
+}
+
+// FieldName returns the name of the field as the client sees it
+func FieldName(f reflect.StructField) string {
+	for _, key := range []string{"json", "query", "path", "header"} {
+		name, _, _ := strings.Cut(f.Tag.Get(key), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+func validateStruct(rv reflect.Value, prefix string) []FieldError {
+	var errs []FieldError
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := prefix + FieldName(f)
+		fv := rv.Field(i)
+
+		tag := f.Tag.Get("validate")
+		if tag != "" && tag != "-" {
+			errs = append(errs, validateField(fv, name, tag)...)
+		}
+
+		inner := reflect.Indirect(fv)
+		if inner.Kind() == reflect.Struct && inner.Type() != reflect.TypeOf(time.Time{}) {
+			errs = append(errs, validateStruct(inner, name+".")...)
+		}
+	}
+	return errs
+}
+
+func validateField(fv reflect.Value, name, tag string) []FieldError {
+	var errs []FieldError
+
+	rules := Rules(tag)
+	if fv.IsZero() {
+		for _, r := range rules {
+			if r.Name == RuleRequired {
+				return []FieldError{{name, RuleRequired, "", "is required"}}
+			}
+		}
+		if !isNumber(fv.Kind()) {
+			return nil
+		}
+	}
+
+	fv = reflect.Indirect(fv)
+	for _, r := range rules {
+		if err := checkRule(fv, r); err != "" {
+			errs = append(errs, FieldError{name, r.Name, r.Param, err})
+		}
+	}
+	return errs
+}
+
+func isNumber(k reflect.Kind) bool {
+	switch k { //nolint:exhaustive //other kinds are not numbers
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+type Rule struct {
+	Name  string
+	Param string
+}
+
+// Rules parses a "validate" tag
+func Rules(tag string) []Rule {
+	var rules []Rule
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, RuleRegex+"=") {
+			part, tag = tag, ""
+		} else {
+			part, tag, _ = strings.Cut(tag, ",")
+		}
+		name, param, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name != "" {
+			rules = append(rules, Rule{name, param})
+		}
+	}
+	return rules
+}
+
+func checkRule(fv reflect.Value, r Rule) string {
+	switch r.Name {
+	case RuleRequired:
+		return ""
+	case RuleMin, RuleMax:
+		return checkRange(fv, r)
+	case RuleOneOf:
+		options := strings.Split(r.Param, "|")
+		for _, s := range values(fv) {
+			if !slices.Contains(options, s) {
+				return "must be one of " + strings.Join(options, ", ")
+			}
+		}
+	case RuleDate:
+		layout := r.Param
+		if layout == "" {
+			layout = time.DateOnly
+		}
+		for _, s := range values(fv) {
+			if _, err := time.Parse(layout, s); err != nil {
+				return "must be a date in format " + layout
+			}
+		}
+	case RuleRegex:
+		re, err := compile(r.Param)
+		if err != nil {
+			return "has invalid pattern " + r.Param
+		}
+		for _, s := range values(fv) {
+			if !re.MatchString(s) {
+				return "must match " + r.Param
+			}
+		}
+	default:
+		return "has unknown rule " + r.Name
+	}
+	return ""
+}
+
+// checkRange compares numbers by value and strings, slices and maps by length
+func checkRange(fv reflect.Value, r Rule) string {
+	limit, err := strconv.ParseFloat(r.Param, 64)
+	if err != nil {
+		return "has invalid " + r.Name + " " + r.Param
+	}
+
+	var v float64
+	what := ""
+	switch fv.Kind() { //nolint:exhaustive //other kinds have no range
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v = float64(fv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v = float64(fv.Uint())
+	case reflect.Float32, reflect.Float64:
+		v = fv.Float()
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		v = float64(fv.Len())
+		what = "length "
+	default:
+		return ""
+	}
+
+	if r.Name == RuleMin && v < limit {
+		return fmt.Sprintf("%smust be at least %s", what, r.Param)
+	}
+	if r.Name == RuleMax && v > limit {
+		return fmt.Sprintf("%smust be at most %s", what, r.Param)
+	}
+	return ""
+}
+
+// values returns the string forms of a value or of every element of a slice
+func values(fv reflect.Value) []string {
+	if fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array {
+		res := make([]string, 0, fv.Len())
+		for i := range fv.Len() {
+			res = append(res, fmt.Sprint(reflect.Indirect(fv.Index(i)).Interface()))
+		}
+		return res
+	}
+	return []string{fmt.Sprint(fv.Interface())}
+}
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil //nolint:forcetypeassert //only regexps are stored
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexps.Store(pattern, re)
+	return re, nil
+}